@@ -2,28 +2,51 @@ package adapter
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"time"
 
+	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/varbin"
 )
 
 type Cache interface {
 	Start() error
 	Close() error
+	// Ping checks whether the cache backend is reachable before the context is done.
+	Ping(ctx context.Context) error
 	LoadBinary(tag string) (*SavedBinary, error)
 	SaveBinary(tag string, binary *SavedBinary) error
+	// Delete removes the binary of the tag, removing missing tags is not an error.
+	Delete(tag string) error
+	// List returns entries of tags starting with the prefix, contents are not required to be loaded.
+	List(prefix string) ([]CacheEntry, error)
 }
 
+type CacheEntry struct {
+	Tag string
+	// Size is the length of the content.
+	Size int
+	// Binary holds metadata of the entry, where Content may be left empty.
+	Binary *SavedBinary
+}
+
+// SavedBinaryHeaderSize is the maximum size of the version and the content length prefixing marshaled binaries.
+const SavedBinaryHeaderSize = 1 + binary.MaxVarintLen64
+
 type SavedBinary struct {
 	Content     []byte
 	LastUpdated time.Time
 	LastEtag    string
+	Report      *ConvertReport
+	// SourceDigest identifies the source contents the binary is converted from, or the content itself for sources,
+	// which is not changed by not modified responses.
+	SourceDigest string
 }
 
 func (s *SavedBinary) MarshalBinary() ([]byte, error) {
 	var buffer bytes.Buffer
-	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	err := binary.Write(&buffer, binary.BigEndian, uint8(3))
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +62,14 @@ func (s *SavedBinary) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = varbin.Write(&buffer, binary.BigEndian, s.Report.Items())
+	if err != nil {
+		return nil, err
+	}
+	err = varbin.Write(&buffer, binary.BigEndian, s.SourceDigest)
+	if err != nil {
+		return nil, err
+	}
 	return buffer.Bytes(), nil
 }
 
@@ -53,8 +84,34 @@ func (s *SavedBinary) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
+	return s.unmarshalMetadata(version, reader)
+}
+
+// SavedBinaryContentRange returns the offset and the length of the content from the header of the marshaled binary,
+// metadata follows the content, so that it can be loaded without the content.
+func SavedBinaryContentRange(header []byte) (offset int, length int, err error) {
+	if len(header) < 2 {
+		return 0, 0, E.New("invalid binary header")
+	}
+	contentLength, n := binary.Uvarint(header[1:])
+	if n <= 0 {
+		return 0, 0, E.New("invalid binary header")
+	}
+	return 1 + n, int(contentLength), nil
+}
+
+// UnmarshalMetadata unmarshals the header and the metadata following the content of the marshaled binary,
+// Content is left empty.
+func (s *SavedBinary) UnmarshalMetadata(header []byte, metadata []byte) error {
+	if len(header) == 0 {
+		return E.New("invalid binary header")
+	}
+	return s.unmarshalMetadata(header[0], bytes.NewReader(metadata))
+}
+
+func (s *SavedBinary) unmarshalMetadata(version uint8, reader *bytes.Reader) error {
 	var lastUpdated int64
-	err = binary.Read(reader, binary.BigEndian, &lastUpdated)
+	err := binary.Read(reader, binary.BigEndian, &lastUpdated)
 	if err != nil {
 		return err
 	}
@@ -63,5 +120,22 @@ func (s *SavedBinary) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	if version < 2 {
+		return nil
+	}
+	var reportItems []ConvertReportItem
+	err = varbin.Read(reader, binary.BigEndian, &reportItems)
+	if err != nil {
+		return err
+	}
+	if len(reportItems) > 0 {
+		s.Report = &ConvertReport{}
+		for _, item := range reportItems {
+			s.Report.Drop(item.Reason, int(item.Count), item.Samples...)
+		}
+	}
+	if version < 3 {
+		return nil
+	}
+	return varbin.Read(reader, binary.BigEndian, &s.SourceDigest)
 }
@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedBinaryMetadata(t *testing.T) {
+	t.Parallel()
+	savedBinary := &SavedBinary{
+		Content:      make([]byte, 1000),
+		LastUpdated:  time.Unix(1750291200, 0),
+		LastEtag:     "\"abc\"",
+		Report:       &ConvertReport{},
+		SourceDigest: "digest",
+	}
+	savedBinary.Report.Drop("unsupported", 1, "sample")
+	data, err := savedBinary.MarshalBinary()
+	require.NoError(t, err)
+	header := data[:SavedBinaryHeaderSize]
+	offset, length, err := SavedBinaryContentRange(header)
+	require.NoError(t, err)
+	require.Equal(t, len(savedBinary.Content), length)
+	require.Equal(t, savedBinary.Content, data[offset:offset+length])
+	var metadata SavedBinary
+	require.NoError(t, metadata.UnmarshalMetadata(header, data[offset+length:]))
+	require.Empty(t, metadata.Content)
+	require.Equal(t, savedBinary.LastUpdated, metadata.LastUpdated)
+	require.Equal(t, savedBinary.LastEtag, metadata.LastEtag)
+	require.Equal(t, savedBinary.Report.Items(), metadata.Report.Items())
+	require.Equal(t, savedBinary.SourceDigest, metadata.SourceDigest)
+	_, _, err = SavedBinaryContentRange(nil)
+	require.Error(t, err)
+}
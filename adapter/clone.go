@@ -0,0 +1,42 @@
+package adapter
+
+import "reflect"
+
+// CloneRules returns a copy of rules sharing no slices with the original,
+// so that rules shared across conversions can be modified by convertors.
+func CloneRules(rules []Rule) []Rule {
+	if rules == nil {
+		return nil
+	}
+	clonedRules := make([]Rule, len(rules))
+	copy(clonedRules, rules)
+	for i := range clonedRules {
+		cloneValue(reflect.ValueOf(&clonedRules[i]).Elem())
+	}
+	return clonedRules
+}
+
+func cloneValue(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Field(i)
+			if field.CanSet() {
+				cloneValue(field)
+			}
+		}
+	case reflect.Slice:
+		if value.IsNil() {
+			return
+		}
+		clonedValue := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(clonedValue, value)
+		switch value.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Slice:
+			for i := 0; i < clonedValue.Len(); i++ {
+				cloneValue(clonedValue.Index(i))
+			}
+		}
+		value.Set(clonedValue)
+	}
+}
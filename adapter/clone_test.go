@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/ranges"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneRules(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, CloneRules(nil))
+	rules := []Rule{
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: DefaultRule{
+				DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+					Domain: []string{"example.com"},
+				},
+				IPASN: []string{"13335"},
+				DSCP:  []ranges.Range[uint8]{ranges.New[uint8](1, 2)},
+			},
+		},
+		{
+			Type: boxConstant.RuleTypeLogical,
+			LogicalOptions: LogicalRule{
+				Mode: boxConstant.LogicalTypeAnd,
+				Rules: []Rule{{
+					Type: boxConstant.RuleTypeDefault,
+					DefaultOptions: DefaultRule{
+						DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+							DomainSuffix: []string{"example.org"},
+						},
+					},
+				}},
+			},
+		},
+	}
+	clonedRules := CloneRules(rules)
+	require.Equal(t, rules, clonedRules)
+	clonedRules[0].DefaultOptions.Domain[0] = "changed.com"
+	clonedRules[0].DefaultOptions.IPASN = append(clonedRules[0].DefaultOptions.IPASN[:0], "0")
+	clonedRules[0].DefaultOptions.DSCP[0] = ranges.New[uint8](3, 4)
+	clonedRules[1].LogicalOptions.Rules[0].DefaultOptions.DomainSuffix[0] = "changed.org"
+	clonedRules[1].LogicalOptions.Rules[0].Type = boxConstant.RuleTypeLogical
+	require.Equal(t, []string{"example.com"}, []string(rules[0].DefaultOptions.Domain))
+	require.Equal(t, []string{"13335"}, rules[0].DefaultOptions.IPASN)
+	require.Equal(t, ranges.New[uint8](1, 2), rules[0].DefaultOptions.DSCP[0])
+	require.Equal(t, []string{"example.org"}, []string(rules[1].LogicalOptions.Rules[0].DefaultOptions.DomainSuffix))
+	require.Equal(t, boxConstant.RuleTypeDefault, rules[1].LogicalOptions.Rules[0].Type)
+}
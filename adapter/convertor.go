@@ -14,7 +14,20 @@ type Convertor interface {
 	To(ctx context.Context, contentRules []Rule, options ConvertOptions) ([]byte, error)
 }
 
+// BundleConvertor is a target convertor that can encode multiple named categories into one file.
+type BundleConvertor interface {
+	Convertor
+	ToBundle(ctx context.Context, categories []RuleCategory, options ConvertOptions) ([]byte, error)
+}
+
+type RuleCategory struct {
+	Name  string
+	Rules []Rule
+}
+
 type ConvertOptions struct {
 	Options  option.ConvertOptions
 	Metadata C.Metadata
+	Params   map[string]string
+	Report   *ConvertReport
 }
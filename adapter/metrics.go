@@ -0,0 +1,12 @@
+package adapter
+
+import "time"
+
+// Metrics records metrics of the server, which is registered in the service context only if metrics are enabled.
+type Metrics interface {
+	ObserveRequest(endpoint string, status int)
+	ObserveCache(backend string, hit bool)
+	ObserveFetch(source string, duration time.Duration, notModified bool, err error)
+	ObserveConversion(convertor string, duration time.Duration, dropped int)
+	ObserveResource(resource string, err error)
+}
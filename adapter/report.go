@@ -0,0 +1,125 @@
+package adapter
+
+import (
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
+)
+
+const maxReportSamples = 5
+
+// ConvertReport collects items dropped by lossy conversions, grouped by reason.
+// All methods are safe to call on a nil report.
+type ConvertReport struct {
+	items []*ConvertReportItem
+}
+
+type ConvertReportItem struct {
+	Reason  string   `json:"reason"`
+	Count   int64    `json:"count"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+func (r *ConvertReport) Drop(reason string, count int, samples ...string) {
+	if r == nil || count == 0 {
+		return
+	}
+	var item *ConvertReportItem
+	for _, it := range r.items {
+		if it.Reason == reason {
+			item = it
+			break
+		}
+	}
+	if item == nil {
+		item = &ConvertReportItem{Reason: reason}
+		r.items = append(r.items, item)
+	}
+	item.Count += int64(count)
+	for _, sample := range samples {
+		if len(item.Samples) >= maxReportSamples {
+			break
+		}
+		item.Samples = append(item.Samples, sample)
+	}
+}
+
+// Merge adds all items of another report.
+func (r *ConvertReport) Merge(other *ConvertReport) {
+	if other == nil {
+		return
+	}
+	for _, item := range other.items {
+		r.Drop(item.Reason, int(item.Count), item.Samples...)
+	}
+}
+
+// DropRule reports a rule that cannot be expressed by the target.
+func (r *ConvertReport) DropRule(rule Rule, target string) {
+	if rule.Type == boxConstant.RuleTypeLogical {
+		r.Drop("logical rules unsupported by "+target, 1)
+	} else {
+		r.Drop("rules with non-destination address items unsupported by "+target, 1)
+	}
+}
+
+// DropDestinationItems reports destination address items of the rule except the kept ones as unsupported by the target.
+func (r *ConvertReport) DropDestinationItems(rule DefaultRule, target string, keep ...string) {
+	for _, item := range []struct {
+		name   string
+		values []string
+	}{
+		{"domain", rule.Domain},
+		{"domain_suffix", rule.DomainSuffix},
+		{"domain_keyword", rule.DomainKeyword},
+		{"domain_regex", rule.DomainRegex},
+		{"ip_cidr", rule.IPCIDR},
+		{"GEOIP", rule.GEOIP},
+		{"IPASN", rule.IPASN},
+	} {
+		if common.Contains(keep, item.name) {
+			continue
+		}
+		r.Drop(item.name+" items unsupported by "+target, len(item.values), item.values...)
+	}
+}
+
+func (r *ConvertReport) Dropped() int {
+	if r == nil {
+		return 0
+	}
+	var dropped int
+	for _, item := range r.items {
+		dropped += int(item.Count)
+	}
+	return dropped
+}
+
+func (r *ConvertReport) Items() []ConvertReportItem {
+	if r == nil {
+		return nil
+	}
+	items := make([]ConvertReportItem, 0, len(r.items))
+	for _, item := range r.items {
+		items = append(items, *item)
+	}
+	return items
+}
+
+func (r *ConvertReport) String() string {
+	if r == nil {
+		return ""
+	}
+	itemStrings := make([]string, 0, len(r.items))
+	for _, item := range r.items {
+		itemStrings = append(itemStrings, F.ToString(item.Reason, ": ", item.Count))
+	}
+	return strings.Join(itemStrings, "; ")
+}
+
+// ConvertReportStore keeps the latest conversion report of each requested path.
+type ConvertReportStore interface {
+	StoreReport(path string, report *ConvertReport)
+}
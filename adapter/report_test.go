@@ -0,0 +1,41 @@
+package adapter
+
+import (
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertReport(t *testing.T) {
+	t.Parallel()
+	var report ConvertReport
+	report.Drop("rules with path", 6, "a", "b", "c", "d", "e", "f")
+	report.Drop("empty", 0)
+	report.DropRule(Rule{Type: boxConstant.RuleTypeLogical}, "hosts")
+	require.Equal(t, 7, report.Dropped())
+	var other ConvertReport
+	other.Drop("rules with path", 1, "g")
+	other.DropDestinationItems(DefaultRule{IPASN: []string{"13335"}}, "hosts", "domain")
+	report.Merge(&other)
+	report.Merge(nil)
+	require.Equal(t, []ConvertReportItem{
+		{Reason: "rules with path", Count: 7, Samples: []string{"a", "b", "c", "d", "e"}},
+		{Reason: "logical rules unsupported by hosts", Count: 1},
+		{Reason: "IPASN items unsupported by hosts", Count: 1, Samples: []string{"13335"}},
+	}, report.Items())
+	require.Equal(t, 9, report.Dropped())
+	require.Equal(t, "rules with path: 7; logical rules unsupported by hosts: 1; IPASN items unsupported by hosts: 1", report.String())
+	binary := &SavedBinary{Content: []byte("content"), Report: &report}
+	content, err := binary.MarshalBinary()
+	require.NoError(t, err)
+	var loaded SavedBinary
+	require.NoError(t, loaded.UnmarshalBinary(content))
+	require.Equal(t, report.Items(), loaded.Report.Items())
+	var nilReport *ConvertReport
+	nilReport.Drop("ignored", 1)
+	nilReport.Merge(&report)
+	require.Zero(t, nilReport.Dropped())
+	require.Empty(t, nilReport.Items())
+}
@@ -16,10 +16,19 @@ type ResourceManager interface {
 	GEOSite(code string) (*option.DefaultHeadlessRule, error)
 	IPASNConfigured() bool
 	IPASN(asn string) (*option.DefaultHeadlessRule, error)
+	// Refresh fetches the source of the resource code ignoring the cache, resource is one of geoip, geosite and ipasn.
+	Refresh(resource string, code string) (string, error)
+	// Preload fetches sources of resources using a single database for all codes.
+	Preload() error
+	// CheckLoaded returns an error if a resource using a single database for all codes has never been loaded.
+	CheckLoaded() error
 }
 
 func EmbedResourceRules(ctx context.Context, rules []Rule) ([]Rule, error) {
 	resourceManager := service.FromContext[ResourceManager](ctx)
+	if resourceManager == nil {
+		return rules, nil
+	}
 	for index, rule := range rules {
 		err := embedResourceRule(ctx, resourceManager, &rule)
 		if err != nil {
@@ -56,6 +56,14 @@ type DefaultRule struct {
 	InboundType []string
 	InboundPort []ranges.Range[uint16]
 	InboundUser []string
+
+	IPSuffix       []string
+	SourceIPSuffix []string
+	DSCP           []ranges.Range[uint8]
+	UID            []ranges.Range[uint32]
+
+	RuleSet   []string
+	DomainSet []string
 }
 
 func DefaultRuleFrom(rule boxOption.DefaultHeadlessRule) DefaultRule {
@@ -67,7 +75,9 @@ func DefaultRuleFrom(rule boxOption.DefaultHeadlessRule) DefaultRule {
 func (r DefaultRule) Headlessable() bool {
 	return len(r.GEOIP) == 0 && len(r.SourceGEOIP) == 0 &&
 		len(r.IPASN) == 0 && len(r.SourceIPASN) == 0 &&
-		len(r.Inbound) == 0 && len(r.InboundType) == 0 && len(r.InboundPort) == 0 && len(r.InboundUser) == 0
+		len(r.Inbound) == 0 && len(r.InboundType) == 0 && len(r.InboundPort) == 0 && len(r.InboundUser) == 0 &&
+		len(r.IPSuffix) == 0 && len(r.SourceIPSuffix) == 0 && len(r.DSCP) == 0 && len(r.UID) == 0 &&
+		len(r.RuleSet) == 0 && len(r.DomainSet) == 0
 }
 
 func (r DefaultRule) ToHeadless() boxOption.DefaultHeadlessRule {
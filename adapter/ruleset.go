@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"context"
+	"reflect"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/option"
+)
+
+type RuleSetResolver interface {
+	Resolve(ctx context.Context, reference string, urlOptions option.SourceConvertOptions) ([]Rule, error)
+}
+
+// RuleSetURLOptions returns the convert options for referenced rule-set URLs,
+// since the format of URL references is determined by the referencing rule-set.
+type RuleSetURLOptions func(reference string, domainSet bool) option.SourceConvertOptions
+
+// ResolveRuleSets inlines RULE-SET and DOMAIN-SET references,
+// references are kept if no rule-set resolver is configured.
+func ResolveRuleSets(ctx context.Context, rules []Rule, urlOptions RuleSetURLOptions) ([]Rule, error) {
+	resolver := service.FromContext[RuleSetResolver](ctx)
+	if resolver == nil {
+		return rules, nil
+	}
+	var resolved bool
+	outputRules := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !hasRuleSetReference(rule) {
+			outputRules = append(outputRules, rule)
+			continue
+		}
+		resolved = true
+		if rule.Type == boxConstant.RuleTypeDefault && !rule.DefaultOptions.Invert {
+			referencedRules, err := resolveRuleSetReferences(ctx, resolver, rule.DefaultOptions, urlOptions)
+			if err != nil {
+				return nil, err
+			}
+			outputRules = append(outputRules, referencedRules...)
+		} else {
+			err := resolveRuleSetRule(ctx, resolver, &rule, urlOptions)
+			if err != nil {
+				return nil, err
+			}
+			outputRules = append(outputRules, rule)
+		}
+	}
+	if !resolved {
+		return rules, nil
+	}
+	return MergeRules(outputRules), nil
+}
+
+func hasRuleSetReference(rule Rule) bool {
+	if rule.Type == boxConstant.RuleTypeLogical {
+		for _, subRule := range rule.LogicalOptions.Rules {
+			if hasRuleSetReference(subRule) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(rule.DefaultOptions.RuleSet) > 0 || len(rule.DefaultOptions.DomainSet) > 0
+}
+
+func resolveRuleSetRule(ctx context.Context, resolver RuleSetResolver, rule *Rule, urlOptions RuleSetURLOptions) error {
+	if rule.Type == boxConstant.RuleTypeLogical {
+		for index, subRule := range rule.LogicalOptions.Rules {
+			err := resolveRuleSetRule(ctx, resolver, &subRule, urlOptions)
+			if err != nil {
+				return err
+			}
+			rule.LogicalOptions.Rules[index] = subRule
+		}
+		return nil
+	}
+	if len(rule.DefaultOptions.RuleSet) == 0 && len(rule.DefaultOptions.DomainSet) == 0 {
+		return nil
+	}
+	referencedRules, err := resolveRuleSetReferences(ctx, resolver, rule.DefaultOptions, urlOptions)
+	if err != nil {
+		return err
+	}
+	if len(referencedRules) == 1 && (!rule.DefaultOptions.Invert || referencedRules[0].Type == boxConstant.RuleTypeLogical) {
+		invert := rule.DefaultOptions.Invert
+		*rule = referencedRules[0]
+		if invert {
+			rule.LogicalOptions.Invert = !rule.LogicalOptions.Invert
+		}
+		return nil
+	}
+	*rule = Rule{
+		Type: boxConstant.RuleTypeLogical,
+		LogicalOptions: LogicalRule{
+			Mode:   boxConstant.LogicalTypeOr,
+			Rules:  referencedRules,
+			Invert: rule.DefaultOptions.Invert,
+		},
+	}
+	return nil
+}
+
+func resolveRuleSetReferences(ctx context.Context, resolver RuleSetResolver, rule DefaultRule, urlOptions RuleSetURLOptions) ([]Rule, error) {
+	var rules []Rule
+	for _, ruleSet := range rule.RuleSet {
+		referencedRules, err := resolver.Resolve(ctx, ruleSet, urlOptions(ruleSet, false))
+		if err != nil {
+			return nil, E.Cause(err, "resolve RULE-SET ", ruleSet)
+		}
+		rules = append(rules, referencedRules...)
+	}
+	for _, domainSet := range rule.DomainSet {
+		referencedRules, err := resolver.Resolve(ctx, domainSet, urlOptions(domainSet, true))
+		if err != nil {
+			return nil, E.Cause(err, "resolve DOMAIN-SET ", domainSet)
+		}
+		rules = append(rules, referencedRules...)
+	}
+	var referenceRule DefaultRule
+	referenceRule.RuleSet = rule.RuleSet
+	referenceRule.DomainSet = rule.DomainSet
+	referenceRule.Invert = rule.Invert
+	if !reflect.DeepEqual(rule, referenceRule) {
+		return nil, E.New("unexpected rule items with rule-set references")
+	}
+	return rules, nil
+}
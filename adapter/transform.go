@@ -0,0 +1,8 @@
+package adapter
+
+import "context"
+
+// Transform modifies rules decoded from the source before they are encoded to the target.
+type Transform interface {
+	Apply(ctx context.Context, rules []Rule, options ConvertOptions) ([]Rule, error)
+}
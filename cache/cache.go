@@ -4,18 +4,35 @@ import (
 	"context"
 
 	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
 	"github.com/sagernet/srsc/adapter"
 	C "github.com/sagernet/srsc/constant"
 	"github.com/sagernet/srsc/option"
 )
 
 func New(ctx context.Context, options option.CacheOptions) (adapter.Cache, error) {
+	var (
+		cache adapter.Cache
+		err   error
+	)
 	switch options.Type {
 	case C.CacheTypeMemory, "":
-		return NewMemory(options.Expiration), nil
+		options.Type = C.CacheTypeMemory
+		cache = NewMemory(options.Expiration)
 	case C.CacheTypeRedis:
-		return NewRedis(ctx, options.Expiration, options.RedisOptions)
+		cache, err = NewRedis(ctx, options.Expiration, options.RedisOptions)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, E.New("unknown cache type: ", options.Type)
 	}
+	if metrics := service.FromContext[adapter.Metrics](ctx); metrics != nil {
+		cache = &metricsCache{
+			Cache:   cache,
+			backend: options.Type,
+			metrics: metrics,
+		}
+	}
+	return cache, nil
 }
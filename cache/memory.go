@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/sagernet/sing/common"
@@ -31,6 +33,10 @@ func (c *MemoryCache) Close() error {
 	return nil
 }
 
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (c *MemoryCache) LoadBinary(tag string) (*adapter.SavedBinary, error) {
 	savedBinary, loaded := c.Get(tag)
 	if !loaded {
@@ -43,3 +49,27 @@ func (c *MemoryCache) SaveBinary(tag string, binary *adapter.SavedBinary) error
 	c.Add(tag, binary)
 	return nil
 }
+
+func (c *MemoryCache) Delete(tag string) error {
+	c.Remove(tag)
+	return nil
+}
+
+func (c *MemoryCache) List(prefix string) ([]adapter.CacheEntry, error) {
+	var entries []adapter.CacheEntry
+	for _, tag := range c.Keys() {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		savedBinary, loaded := c.Peek(tag)
+		if !loaded {
+			continue
+		}
+		entries = append(entries, adapter.CacheEntry{
+			Tag:    tag,
+			Size:   len(savedBinary.Content),
+			Binary: savedBinary,
+		})
+	}
+	return entries, nil
+}
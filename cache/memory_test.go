@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryList(t *testing.T) {
+	t.Parallel()
+	cache := NewMemory(0)
+	for _, tag := range []string{"source.a", "source.b", "file.0.a"} {
+		require.NoError(t, cache.SaveBinary(tag, &adapter.SavedBinary{
+			Content:     []byte(tag),
+			LastUpdated: time.Now(),
+		}))
+	}
+	entries, err := cache.List("source.")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.NoError(t, cache.Delete("source.a"))
+	require.NoError(t, cache.Delete("source.missing"))
+	entries, err = cache.List("")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	savedBinary, err := cache.LoadBinary("source.a")
+	require.NoError(t, err)
+	require.Nil(t, savedBinary)
+}
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"github.com/sagernet/srsc/adapter"
+)
+
+var _ adapter.Cache = (*metricsCache)(nil)
+
+// metricsCache records hits and misses of the cache backend.
+type metricsCache struct {
+	adapter.Cache
+	backend string
+	metrics adapter.Metrics
+}
+
+func (c *metricsCache) LoadBinary(tag string) (*adapter.SavedBinary, error) {
+	binary, err := c.Cache.LoadBinary(tag)
+	if err == nil {
+		c.metrics.ObserveCache(c.backend, binary != nil)
+	}
+	return binary, err
+}
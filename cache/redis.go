@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/common/tls"
 	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/srsc/adapter"
 	"github.com/sagernet/srsc/option"
 
@@ -69,6 +72,10 @@ func NewRedis(ctx context.Context, expiration time.Duration, options option.Redi
 }
 
 func (r *RedisCache) Start() error {
+	err := r.Ping(r.ctx)
+	if err != nil {
+		return E.Cause(err, "ping redis")
+	}
 	return nil
 }
 
@@ -76,6 +83,10 @@ func (r *RedisCache) Close() error {
 	return r.client.Close()
 }
 
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisCache) LoadBinary(tag string) (*adapter.SavedBinary, error) {
 	binaryBytes, err := r.client.Get(r.ctx, tag).Bytes()
 	if err != nil {
@@ -104,3 +115,128 @@ func (r *RedisCache) SaveBinary(tag string, binary *adapter.SavedBinary) error {
 	}
 	return nil
 }
+
+func (r *RedisCache) Delete(tag string) error {
+	return r.client.Del(r.ctx, tag).Err()
+}
+
+// List scans keys of the prefix on all masters, keys of other applications sharing the database are skipped,
+// including keys of other types than strings.
+// Only headers and metadata following contents are loaded, by two pipelined ranges per batch of keys.
+func (r *RedisCache) List(prefix string) ([]adapter.CacheEntry, error) {
+	pattern := redisPatternEscaper.Replace(prefix) + "*"
+	var (
+		access  sync.Mutex
+		entries []adapter.CacheEntry
+	)
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		var tags []string
+		iterator := client.Scan(ctx, 0, pattern, redisListBatchSize).Iterator()
+		for iterator.Next(ctx) {
+			tags = append(tags, iterator.Val())
+			if len(tags) < redisListBatchSize {
+				continue
+			}
+			batchEntries, err := loadRedisEntries(ctx, client, tags)
+			if err != nil {
+				return err
+			}
+			access.Lock()
+			entries = append(entries, batchEntries...)
+			access.Unlock()
+			tags = tags[:0]
+		}
+		err := iterator.Err()
+		if err != nil {
+			return err
+		}
+		batchEntries, err := loadRedisEntries(ctx, client, tags)
+		if err != nil {
+			return err
+		}
+		access.Lock()
+		entries = append(entries, batchEntries...)
+		access.Unlock()
+		return nil
+	}
+	var err error
+	if clusterClient, isCluster := r.client.(*redis.ClusterClient); isCluster {
+		err = clusterClient.ForEachMaster(r.ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(r.ctx, r.client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+const redisListBatchSize = 1000
+
+// loadRedisEntries loads metadata of the keys without contents,
+// keys deleted, of other types or not holding binaries are skipped.
+func loadRedisEntries(ctx context.Context, client redis.Cmdable, tags []string) ([]adapter.CacheEntry, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	headerCommands := make([]*redis.StringCmd, len(tags))
+	_, _ = client.Pipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		for i, tag := range tags {
+			headerCommands[i] = pipeliner.GetRange(ctx, tag, 0, adapter.SavedBinaryHeaderSize-1)
+		}
+		return nil
+	})
+	headers := make([][]byte, len(tags))
+	contentLengths := make([]int, len(tags))
+	metadataCommands := make([]*redis.StringCmd, len(tags))
+	_, _ = client.Pipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		for i, tag := range tags {
+			header, err := headerCommands[i].Bytes()
+			if err != nil {
+				continue
+			}
+			offset, length, err := adapter.SavedBinaryContentRange(header)
+			if err != nil {
+				continue
+			}
+			headers[i] = header
+			contentLengths[i] = length
+			metadataCommands[i] = pipeliner.GetRange(ctx, tag, int64(offset+length), -1)
+		}
+		return nil
+	})
+	var entries []adapter.CacheEntry
+	for i, tag := range tags {
+		err := headerCommands[i].Err()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || redis.HasErrorPrefix(err, "WRONGTYPE") {
+				continue
+			}
+			return nil, err
+		}
+		if metadataCommands[i] == nil {
+			continue
+		}
+		metadata, err := metadataCommands[i].Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || redis.HasErrorPrefix(err, "WRONGTYPE") {
+				continue
+			}
+			return nil, err
+		}
+		binary := &adapter.SavedBinary{}
+		if binary.UnmarshalMetadata(headers[i], metadata) != nil {
+			continue
+		}
+		entries = append(entries, adapter.CacheEntry{
+			Tag:    tag,
+			Size:   contentLengths[i],
+			Binary: binary,
+		})
+	}
+	return entries, nil
+}
+
+var redisPatternEscaper = strings.NewReplacer("\\", "\\\\", "*", "\\*", "?", "\\?", "[", "\\[", "]", "\\]", "^", "\\^")
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/srsc/endpoint"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandAdminFlagURL      string
+	commandAdminFlagToken    string
+	commandAdminFlagPrefix   bool
+	commandAdminFlagResource string
+)
+
+var commandAdmin = &cobra.Command{
+	Use:   "admin",
+	Short: "Manage the cache of a running service through the admin API",
+}
+
+var commandAdminList = &cobra.Command{
+	Use:   "list [prefix]",
+	Short: "List cached entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		var prefix string
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+		err := adminList(prefix)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+var commandAdminPurge = &cobra.Command{
+	Use:   "purge <key>",
+	Short: "Delete a cached entry, or entries of a key prefix",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := adminPurge(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+var commandAdminRefresh = &cobra.Command{
+	Use:   "refresh <path|code>",
+	Short: "Fetch sources of an endpoint path, or a resource code, ignoring the cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		err := adminRefresh(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	commandAdmin.PersistentFlags().StringVarP(&commandAdminFlagURL, "url", "u", "", "admin API URL, derived from configuration by default")
+	commandAdmin.PersistentFlags().StringVarP(&commandAdminFlagToken, "token", "t", "", "admin API token, loaded from configuration by default")
+	commandAdminPurge.Flags().BoolVarP(&commandAdminFlagPrefix, "prefix", "p", false, "delete all entries of keys starting with the argument")
+	commandAdminRefresh.Flags().StringVarP(&commandAdminFlagResource, "resource", "r", "", "refresh the code of the resource (geoip, geosite or ipasn) instead of an endpoint path")
+	commandAdmin.AddCommand(commandAdminList)
+	commandAdmin.AddCommand(commandAdminPurge)
+	commandAdmin.AddCommand(commandAdminRefresh)
+	mainCommand.AddCommand(commandAdmin)
+}
+
+func adminList(prefix string) error {
+	var entries []endpoint.AdminCacheEntry
+	err := adminRequest(http.MethodGet, "/cache", url.Values{"prefix": {prefix}}, &entries)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = writer.Write([]byte("KEY\tSIZE\tAGE\tETAG\n"))
+	for _, entry := range entries {
+		_, _ = writer.Write([]byte(F.ToString(entry.Key, "\t", entry.Size, "\t", time.Duration(entry.Age)*time.Second, "\t", entry.ETag, "\n")))
+	}
+	return writer.Flush()
+}
+
+func adminPurge(key string) error {
+	query := url.Values{}
+	if commandAdminFlagPrefix {
+		query.Set("prefix", key)
+	} else {
+		query.Set("key", key)
+	}
+	var result endpoint.AdminPurgeResult
+	err := adminRequest(http.MethodDelete, "/cache", query, &result)
+	if err != nil {
+		return err
+	}
+	log.Info("deleted ", result.Deleted, " entries")
+	return nil
+}
+
+func adminRefresh(pathOrCode string) error {
+	query := url.Values{}
+	if commandAdminFlagResource != "" {
+		query.Set("resource", commandAdminFlagResource)
+		query.Set("code", pathOrCode)
+	} else {
+		query.Set("path", pathOrCode)
+	}
+	var result endpoint.AdminRefreshResult
+	err := adminRequest(http.MethodPost, "/refresh", query, &result)
+	if err != nil {
+		return err
+	}
+	for _, source := range result.Sources {
+		log.Info("refreshed ", source)
+	}
+	return nil
+}
+
+func adminRequest(method string, path string, query url.Values, result any) error {
+	baseURL, token, username, password, err := adminServer()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(method, strings.TrimSuffix(baseURL, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	} else if username != "" {
+		request.SetBasicAuth(username, password)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(content, &errorResponse) == nil && errorResponse.Error != "" {
+			return E.New(response.Status, ": ", errorResponse.Error)
+		}
+		return E.New(response.Status, ": ", string(bytes.TrimSpace(content)))
+	}
+	return json.Unmarshal(content, result)
+}
+
+// adminServer returns the admin API URL and credentials from flags, or from the configuration of the service.
+func adminServer() (baseURL string, token string, username string, password string, err error) {
+	baseURL = commandAdminFlagURL
+	token = commandAdminFlagToken
+	if baseURL != "" && token != "" {
+		return
+	}
+	options, err := readConfigAndMerge()
+	if err != nil {
+		return
+	}
+	if options.Admin == nil {
+		err = E.New("admin API is not enabled in configuration")
+		return
+	}
+	if baseURL == "" {
+		listenAddr := options.Listen.Build(netip.IPv4Unspecified())
+		if listenAddr.IsUnspecified() {
+			if listenAddr.Is6() {
+				listenAddr = netip.IPv6Loopback()
+			} else {
+				listenAddr = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+			}
+		}
+		scheme := "http"
+		if options.TLS != nil && options.TLS.Enabled {
+			scheme = "https"
+		}
+		adminPath := options.Admin.Path
+		if adminPath == "" {
+			adminPath = "/admin"
+		}
+		baseURL = scheme + "://" + M.SocksaddrFrom(listenAddr, options.ListenPort).String() + adminPath
+	}
+	if token != "" {
+		return
+	}
+	access := options.Admin.Access
+	if access == nil {
+		access = options.Access
+	}
+	if access == nil {
+		return
+	}
+	if len(access.Tokens) > 0 {
+		token, err = access.Tokens[0].Load()
+		if err != nil {
+			err = E.Cause(err, "load admin token")
+		}
+	} else if len(access.Users) > 0 {
+		username = access.Users[0].Username
+		password, err = access.Users[0].Password.Load()
+		if err != nil {
+			err = E.Cause(err, "load admin password")
+		}
+	}
+	return
+}
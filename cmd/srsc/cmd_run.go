@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"os/signal"
@@ -24,6 +25,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var commandRunFlagWatch bool
+
 var commandRun = &cobra.Command{
 	Use:   "run",
 	Short: "Run service",
@@ -36,6 +39,7 @@ var commandRun = &cobra.Command{
 }
 
 func init() {
+	commandRun.Flags().BoolVarP(&commandRunFlagWatch, "watch", "w", false, "reload when configuration files are changed")
 	mainCommand.AddCommand(commandRun)
 }
 
@@ -69,15 +73,9 @@ func readConfigAt(path string) (*OptionsEntry, error) {
 	}, nil
 }
 
-func readConfig() ([]*OptionsEntry, error) {
-	var optionsList []*OptionsEntry
-	for _, path := range configPaths {
-		optionsEntry, err := readConfigAt(path)
-		if err != nil {
-			return nil, err
-		}
-		optionsList = append(optionsList, optionsEntry)
-	}
+// listConfig returns paths of configuration files and JSON files in configuration directories.
+func listConfig() ([]string, error) {
+	paths := append([]string(nil), configPaths...)
 	for _, directory := range configDirectories {
 		entries, err := os.ReadDir(directory)
 		if err != nil {
@@ -87,16 +85,26 @@ func readConfig() ([]*OptionsEntry, error) {
 			if !strings.HasSuffix(entry.Name(), ".json") || entry.IsDir() {
 				continue
 			}
-			optionsEntry, err := readConfigAt(filepath.Join(directory, entry.Name()))
-			if err != nil {
-				return nil, err
-			}
-			optionsList = append(optionsList, optionsEntry)
+			paths = append(paths, filepath.Join(directory, entry.Name()))
 		}
 	}
-	sort.Slice(optionsList, func(i, j int) bool {
-		return optionsList[i].path < optionsList[j].path
-	})
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func readConfig() ([]*OptionsEntry, error) {
+	paths, err := listConfig()
+	if err != nil {
+		return nil, err
+	}
+	var optionsList []*OptionsEntry
+	for _, path := range paths {
+		optionsEntry, err := readConfigAt(path)
+		if err != nil {
+			return nil, err
+		}
+		optionsList = append(optionsList, optionsEntry)
+	}
 	return optionsList, nil
 }
 
@@ -123,10 +131,10 @@ func readConfigAndMerge() (option.Options, error) {
 	return mergedOptions, nil
 }
 
-func create() (*srsc.Server, context.CancelFunc, error) {
+func readOptions() (option.Options, error) {
 	options, err := readConfigAndMerge()
 	if err != nil {
-		return nil, nil, err
+		return option.Options{}, err
 	}
 	if disableColor {
 		if options.Log == nil {
@@ -134,6 +142,14 @@ func create() (*srsc.Server, context.CancelFunc, error) {
 		}
 		options.Log.DisableColor = true
 	}
+	return options, nil
+}
+
+func create() (*srsc.Server, context.CancelFunc, error) {
+	options, err := readOptions()
+	if err != nil {
+		return nil, nil, err
+	}
 	ctx, cancel := context.WithCancel(globalCtx)
 	instance, err := srsc.NewServer(srsc.Options{
 		Context: ctx,
@@ -155,7 +171,7 @@ func create() (*srsc.Server, context.CancelFunc, error) {
 		_, loaded := <-osSignals
 		if loaded {
 			cancel()
-			closeMonitor(startCtx)
+			closeMonitor(startCtx, 0)
 		}
 	}()
 	err = instance.Start()
@@ -167,10 +183,37 @@ func create() (*srsc.Server, context.CancelFunc, error) {
 	return instance, cancel, nil
 }
 
+// reload applies the configuration to the running service in place.
+func reload(instance *srsc.Server) error {
+	options, err := readOptions()
+	if err != nil {
+		return err
+	}
+	err = instance.Reload(options)
+	if err != nil {
+		return err
+	}
+	runtimeDebug.FreeOSMemory()
+	return nil
+}
+
 func run() error {
 	osSignals := make(chan os.Signal, 1)
 	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	defer signal.Stop(osSignals)
+	if commandRunFlagWatch {
+		// changes are reloaded like SIGHUP, a pending signal is not overwritten.
+		watcher, err := newConfigWatcher(func() {
+			select {
+			case osSignals <- syscall.SIGHUP:
+			default:
+			}
+		})
+		if err != nil {
+			return E.Cause(err, "watch configuration")
+		}
+		defer watcher.Close()
+	}
 	for {
 		instance, cancel, err := create()
 		if err != nil {
@@ -180,16 +223,26 @@ func run() error {
 		for {
 			osSignal := <-osSignals
 			if osSignal == syscall.SIGHUP {
+				err = reload(instance)
+				if err == nil {
+					continue
+				}
+				if !errors.Is(err, srsc.ErrRestartRequired) {
+					log.Error(E.Cause(err, "reload service"))
+					continue
+				}
 				err = check()
 				if err != nil {
 					log.Error(E.Cause(err, "reload service"))
 					continue
 				}
+				log.Info("restarting service: ", srsc.ErrRestartRequired)
 			}
-			cancel()
 			closeCtx, closed := context.WithCancel(context.Background())
-			go closeMonitor(closeCtx)
+			go closeMonitor(closeCtx, instance.ShutdownTimeout())
+			// the context is canceled after requests in flight are drained.
 			err = instance.Close()
+			cancel()
 			closed()
 			if osSignal != syscall.SIGHUP {
 				if err != nil {
@@ -202,12 +255,12 @@ func run() error {
 	}
 }
 
-func closeMonitor(ctx context.Context) {
-	time.Sleep(C.FatalStopTimeout)
+// closeMonitor exits if closing does not finish in the shutdown timeout and FatalStopTimeout.
+func closeMonitor(ctx context.Context, shutdownTimeout time.Duration) {
 	select {
 	case <-ctx.Done():
 		return
-	default:
+	case <-time.After(shutdownTimeout + C.FatalStopTimeout):
 	}
 	log.Fatal("srsc did not close!")
 }
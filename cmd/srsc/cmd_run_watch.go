@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is the time to wait for bursts of writes to finish before checking the configuration.
+const watchDebounce = 500 * time.Millisecond
+
+// configWatcher watches directories of configuration files and configuration directories,
+// and calls reload when the configuration content is changed and checked.
+// Directories are watched instead of files to follow files replaced by renaming, like editors and mounted ConfigMaps do.
+type configWatcher struct {
+	watcher     *fsnotify.Watcher
+	files       []string
+	directories []string
+	reload      func()
+	access      sync.Mutex
+	timer       *time.Timer
+	lastContent []byte
+}
+
+func newConfigWatcher(reload func()) (*configWatcher, error) {
+	var (
+		watchPaths  []string
+		files       []string
+		directories []string
+	)
+	for _, path := range configPaths {
+		if path == "stdin" {
+			return nil, E.New("configuration from stdin cannot be watched")
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, absPath)
+		watchPaths = append(watchPaths, filepath.Dir(absPath))
+	}
+	for _, directory := range configDirectories {
+		absPath, err := filepath.Abs(directory)
+		if err != nil {
+			return nil, err
+		}
+		directories = append(directories, absPath)
+		watchPaths = append(watchPaths, absPath)
+	}
+	lastContent, err := readConfigContent()
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, E.Cause(err, "create watcher")
+	}
+	for _, path := range common.Uniq(watchPaths) {
+		err = watcher.Add(path)
+		if err != nil {
+			watcher.Close()
+			return nil, E.Cause(err, "watch ", path)
+		}
+	}
+	w := &configWatcher{
+		watcher:     watcher,
+		files:       files,
+		directories: directories,
+		reload:      reload,
+		lastContent: lastContent,
+	}
+	go w.loopEvents()
+	return w, nil
+}
+
+func (w *configWatcher) loopEvents() {
+	for {
+		select {
+		case event, loaded := <-w.watcher.Events:
+			if !loaded {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !w.isConfig(event.Name) {
+				continue
+			}
+			w.access.Lock()
+			if w.timer != nil {
+				w.timer.Reset(watchDebounce)
+			} else {
+				w.timer = time.AfterFunc(watchDebounce, w.check)
+			}
+			w.access.Unlock()
+		case err, loaded := <-w.watcher.Errors:
+			if !loaded {
+				return
+			}
+			log.Error(E.Cause(err, "watch configuration"))
+		}
+	}
+}
+
+// check reloads if the configuration content is changed and valid, invalid configurations are logged and not retried until changed again.
+func (w *configWatcher) check() {
+	content, err := readConfigContent()
+	if err != nil {
+		log.Error(E.Cause(err, "watch configuration"))
+		return
+	}
+	w.access.Lock()
+	changed := !bytes.Equal(content, w.lastContent)
+	w.lastContent = content
+	w.access.Unlock()
+	if !changed {
+		return
+	}
+	err = check()
+	if err != nil {
+		log.Error(E.Cause(err, "check changed configuration"))
+		return
+	}
+	log.Info("configuration changed, reloading")
+	w.reload()
+}
+
+// isConfig returns whether the path is a configuration file, a JSON file in a configuration directory,
+// or a hidden entry starting with "..", which mounted ConfigMaps swap to update files.
+func (w *configWatcher) isConfig(path string) bool {
+	if common.Contains(w.files, path) || strings.HasPrefix(filepath.Base(path), "..") {
+		return true
+	}
+	return strings.HasSuffix(path, ".json") && common.Contains(w.directories, filepath.Dir(path))
+}
+
+func (w *configWatcher) Close() error {
+	w.access.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.access.Unlock()
+	return w.watcher.Close()
+}
+
+// readConfigContent returns paths and contents of all configuration files for comparing.
+func readConfigContent() ([]byte, error) {
+	paths, err := listConfig()
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, E.Cause(err, "read config at ", path)
+		}
+		buffer.WriteString(path)
+		buffer.WriteByte(0)
+		buffer.Write(content)
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes(), nil
+}
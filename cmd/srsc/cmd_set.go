@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sagernet/sing-box/log"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+	"github.com/sagernet/srsc/ruleset"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	commandSetFlagSourceType string
+	commandSetFlagTargetType string
+	commandSetFlagOutput     string
+)
+
+var commandSet = &cobra.Command{
+	Use:       "set <union|intersection|difference> <file>...",
+	Short:     "Compute union, intersection or difference of rule-sets",
+	ValidArgs: []string{C.SetOperationUnion, C.SetOperationIntersection, C.SetOperationDifference},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := setOperation(args[0], args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+	Args: cobra.MinimumNArgs(3),
+}
+
+func init() {
+	commandSet.Flags().StringVarP(&commandSetFlagSourceType, "source-type", "s", "", "source type of input files")
+	commandSet.Flags().StringVarP(&commandSetFlagTargetType, "target-type", "t", C.ConvertorTypeRuleSetSource, "target type of output")
+	commandSet.Flags().StringVarP(&commandSetFlagOutput, "output", "o", "", "write result to file instead of stdout")
+	err := commandSet.MarkFlagRequired("source-type")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mainCommand.AddCommand(commandSet)
+}
+
+func setOperation(operation string, inputPaths []string) error {
+	sourceConvertor, loaded := convertor.Convertors[commandSetFlagSourceType]
+	if !loaded {
+		return E.New("unknown source type: ", commandSetFlagSourceType)
+	}
+	targetConvertor, loaded := convertor.Convertors[commandSetFlagTargetType]
+	if !loaded {
+		return E.New("unknown target type: ", commandSetFlagTargetType)
+	}
+	convertOptions := adapter.ConvertOptions{
+		Options: option.ConvertOptions{
+			SourceConvertOptions: option.SourceConvertOptions{SourceType: commandSetFlagSourceType},
+			TargetConvertOptions: option.TargetConvertOptions{TargetType: commandSetFlagTargetType},
+		},
+		Report: &adapter.ConvertReport{},
+	}
+	ruleSets := make([][]adapter.Rule, 0, len(inputPaths))
+	for _, inputPath := range inputPaths {
+		content, err := os.ReadFile(inputPath)
+		if err != nil {
+			return E.Cause(err, "read ", inputPath)
+		}
+		rules, err := sourceConvertor.From(globalCtx, content, convertOptions)
+		if err != nil {
+			return E.Cause(err, "decode ", inputPath)
+		}
+		ruleSets = append(ruleSets, rules)
+	}
+	rules, err := ruleset.Operate(operation, ruleSets, convertOptions.Report)
+	if err != nil {
+		return err
+	}
+	content, err := targetConvertor.To(globalCtx, rules, convertOptions)
+	if err != nil {
+		return E.Cause(err, "encode target")
+	}
+	if convertOptions.Report.Dropped() > 0 {
+		log.Warn("dropped ", convertOptions.Report.Dropped(), " items: ", convertOptions.Report.String())
+	}
+	if commandSetFlagOutput == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	err = os.WriteFile(commandSetFlagOutput, content, 0o644)
+	if err != nil {
+		return E.Cause(err, "write output")
+	}
+	return nil
+}
@@ -6,4 +6,16 @@ const (
 	ConvertorTypeAdGuardRuleSet    = "adguard"
 	ConvertorTypeClashRuleProvider = "clash"
 	ConvertorTypeSurgeRuleSet      = "surge"
+	ConvertorTypeHosts             = "hosts"
+	ConvertorTypeDomainList        = "domain-list"
+	ConvertorTypeIPList            = "ip-list"
+	ConvertorTypeDnsmasq           = "dnsmasq"
+	ConvertorTypeSmartDNS          = "smartdns"
+	ConvertorTypeUnbound           = "unbound"
+	ConvertorTypeAdGuardHome       = "adguard-home"
+	ConvertorTypeV2RayGeoSite      = "v2ray-geosite"
+	ConvertorTypeV2RayGeoIP        = "v2ray-geoip"
+	ConvertorTypeMMDB              = "mmdb"
+	ConvertorTypeSingBoxGeoSite    = "sing-box-geosite"
+	ConvertorTypeSingBoxGeoIP      = "sing-box-geoip"
 )
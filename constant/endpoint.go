@@ -4,8 +4,23 @@ import "time"
 
 const DefaultTTL = 5 * time.Minute
 
+const DefaultShutdownTimeout = 5 * time.Second
+
 const (
 	EndpointTypeFile     = "file"
+	EndpointTypeBundle   = "bundle"
+	EndpointTypeSet      = "set"
+	EndpointTypeSplit    = "split"
 	EndpointSourceLocal  = "local"
 	EndpointSourceRemote = "remote"
 )
+
+const (
+	SplitPartDomain = "domain"
+	SplitPartIPCIDR = "ipcidr"
+)
+
+const (
+	LimitByIP    = "ip"
+	LimitByToken = "token"
+)
@@ -9,8 +9,15 @@ import (
 type Platform string
 
 const (
-	PlatformUnknown Platform = ""
-	PlatformSingBox Platform = "sing-box"
+	PlatformUnknown      Platform = ""
+	PlatformSingBox      Platform = "sing-box"
+	PlatformMihomo       Platform = "mihomo"
+	PlatformClash        Platform = "clash"
+	PlatformSurge        Platform = "surge"
+	PlatformStash        Platform = "stash"
+	PlatformLoon         Platform = "loon"
+	PlatformShadowrocket Platform = "shadowrocket"
+	PlatformQuantumultX  Platform = "quantumult-x"
 )
 
 type System string
@@ -30,6 +37,32 @@ type Metadata struct {
 	Version   *semver.Version
 }
 
+type clientPrefix struct {
+	prefix   string
+	platform Platform
+	system   System
+	// version is whether the version following the prefix is the version of the platform,
+	// GUI clients of other cores report their own versions.
+	version bool
+}
+
+// clientPrefixes lists User-Agent prefixes of clients other than sing-box, matched case-insensitively.
+// Versions are only detected in the semantic format, since some clients report build numbers instead.
+var clientPrefixes = []clientPrefix{
+	{"mihomo/", PlatformMihomo, SystemUnknown, true},
+	{"clash.meta/", PlatformMihomo, SystemUnknown, true},
+	{"clashx meta/", PlatformMihomo, SystemMacOS, false},
+	{"clashx/", PlatformClash, SystemMacOS, false},
+	{"surge ios/", PlatformSurge, SystemiOS, true},
+	{"surge mac/", PlatformSurge, SystemMacOS, true},
+	{"surge/", PlatformSurge, SystemUnknown, true},
+	{"stash/", PlatformStash, SystemUnknown, true},
+	{"loon/", PlatformLoon, SystemiOS, true},
+	{"shadowrocket/", PlatformShadowrocket, SystemiOS, true},
+	{"quantumult%20x/", PlatformQuantumultX, SystemUnknown, true},
+	{"quantumult x/", PlatformQuantumultX, SystemUnknown, true},
+}
+
 func DetectMetadata(userAgent string) Metadata {
 	var metadata Metadata
 	metadata.UserAgent = userAgent
@@ -43,6 +76,25 @@ func DetectMetadata(userAgent string) Metadata {
 		metadata.System = SystemAppleTVOS
 	}
 	var versionName string
+	lowerUserAgent := strings.ToLower(userAgent)
+	for _, client := range clientPrefixes {
+		if !strings.HasPrefix(lowerUserAgent, client.prefix) {
+			continue
+		}
+		metadata.Platform = client.platform
+		metadata.System = client.system
+		if client.version {
+			versionName = userAgent[len(client.prefix):]
+			if index := strings.IndexAny(versionName, " ;()"); index != -1 {
+				versionName = versionName[:index]
+			}
+			versionName = strings.TrimPrefix(versionName, "v")
+			if !strings.Contains(versionName, ".") {
+				versionName = ""
+			}
+		}
+		break
+	}
 	if strings.Contains(userAgent, "sing-box ") {
 		metadata.Platform = PlatformSingBox
 		versionName = strings.Split(userAgent, "sing-box ")[1]
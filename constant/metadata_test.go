@@ -0,0 +1,42 @@
+package constant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMetadata(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		userAgent string
+		platform  Platform
+		system    System
+		version   string
+	}{
+		{"SFA/1.11.0 (239; sing-box 1.11.0; language zh_CN)", PlatformSingBox, SystemAndroid, "1.11.0"},
+		{"sing-box 1.10.7", PlatformSingBox, SystemUnknown, "1.10.7"},
+		{"mihomo/1.18.10", PlatformMihomo, SystemUnknown, "1.18.10"},
+		{"clash.meta/v1.16.0", PlatformMihomo, SystemUnknown, "1.16.0"},
+		{"mihomo/alpha-f1b1e7e", PlatformMihomo, SystemUnknown, ""},
+		{"ClashX Meta/v1.4.2 (com.metacubex.ClashX.meta; build:1.4.2; macOS 14.5.0) Alamofire/5.9.1", PlatformMihomo, SystemMacOS, ""},
+		{"ClashX/1.118.0 (com.west2online.ClashX; build:1.118.0; macOS 14.0.0) Alamofire/5.8.0", PlatformClash, SystemMacOS, ""},
+		{"Surge iOS/2920", PlatformSurge, SystemiOS, ""},
+		{"Surge Mac/2460", PlatformSurge, SystemMacOS, ""},
+		{"Stash/2.4.7 Clash/1.9.0", PlatformStash, SystemUnknown, "2.4.7"},
+		{"Loon/726 CFNetwork/1410.0.3 Darwin/22.4.0", PlatformLoon, SystemiOS, ""},
+		{"Shadowrocket/2070 CFNetwork/1410.0.3 Darwin/22.4.0 iPhone14,5", PlatformShadowrocket, SystemiOS, ""},
+		{"Quantumult%20X/1.0.30 (iPhone14,5; iOS 16.4)", PlatformQuantumultX, SystemUnknown, "1.0.30"},
+		{"curl/8.5.0", PlatformUnknown, SystemUnknown, ""},
+	} {
+		metadata := DetectMetadata(testCase.userAgent)
+		require.Equal(t, testCase.platform, metadata.Platform, testCase.userAgent)
+		require.Equal(t, testCase.system, metadata.System, testCase.userAgent)
+		if testCase.version == "" {
+			require.Nil(t, metadata.Version, testCase.userAgent)
+		} else {
+			require.NotNil(t, metadata.Version, testCase.userAgent)
+			require.Equal(t, testCase.version, metadata.Version.String(), testCase.userAgent)
+		}
+	}
+}
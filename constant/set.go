@@ -0,0 +1,7 @@
+package constant
+
+const (
+	SetOperationUnion        = "union"
+	SetOperationIntersection = "intersection"
+	SetOperationDifference   = "difference"
+)
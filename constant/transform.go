@@ -0,0 +1,11 @@
+package constant
+
+const (
+	TransformTypeInclude    = "include"
+	TransformTypeExclude    = "exclude"
+	TransformTypeAdd        = "add"
+	TransformTypeDropFields = "drop_fields"
+	TransformTypeInvert     = "invert"
+	TransformTypeLogical    = "logical"
+	TransformTypeSubtract   = "subtract"
+)
@@ -5,7 +5,6 @@ import (
 	"context"
 
 	E "github.com/sagernet/sing/common/exceptions"
-	"github.com/sagernet/sing/common/logger"
 	"github.com/sagernet/srsc/adapter"
 	C "github.com/sagernet/srsc/constant"
 )
@@ -26,9 +25,9 @@ func (a *RuleSet) From(ctx context.Context, content []byte, options adapter.Conv
 	if options.Options.AdGuardOptions.AcceptExtendedRules && options.Options.TargetType != C.ConvertorTypeAdGuardRuleSet && options.Options.TargetType != C.ConvertorTypeRuleSetBinary {
 		return nil, E.New("AdGuard rule-set can only be converted to sing-box rule-set binary when `accept_extended_rules` enabled")
 	}
-	return ToRules(bytes.NewReader(content), options.Options.AdGuardOptions.AcceptExtendedRules, logger.NOP())
+	return ToRules(bytes.NewReader(content), options.Options.AdGuardOptions.AcceptExtendedRules, options.Report)
 }
 
 func (a *RuleSet) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
-	return FromRules(contentRules)
+	return FromRules(contentRules, options.Report)
 }
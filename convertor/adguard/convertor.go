@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/netip"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -13,9 +14,10 @@ import (
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
-	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	"github.com/sagernet/srsc/adapter"
+
+	mDNS "github.com/miekg/dns"
 )
 
 type adguardRuleLine struct {
@@ -28,14 +30,111 @@ type adguardRuleLine struct {
 	hasEnd         bool
 	isRegexp       bool
 	isImportant    bool
+	modifiers      adguardModifiers
+}
+
+type adguardModifiers struct {
+	queryType    []option.DNSQueryType
+	sourceIPCIDR []string
+	packageName  []string
+	processName  []string
+	denyAllow    []string
 }
 
-func ToRules(reader io.Reader, acceptExtendedRules bool, logger logger.Logger) ([]adapter.Rule, error) {
+func (m *adguardModifiers) parse(name string, value string) error {
+	values := strings.Split(value, "|")
+	for _, item := range values {
+		if item == "" {
+			return E.New("empty value")
+		}
+		if strings.HasPrefix(item, "~") {
+			return E.New("negated value is not supported: ", item)
+		}
+	}
+	switch name {
+	case "dnstype":
+		for _, typeName := range values {
+			queryType, loaded := mDNS.StringToType[strings.ToUpper(typeName)]
+			if !loaded {
+				return E.New("unknown DNS query type: ", typeName)
+			}
+			m.queryType = append(m.queryType, option.DNSQueryType(queryType))
+		}
+	case "client":
+		for _, client := range values {
+			client = strings.TrimSuffix(strings.TrimPrefix(client, "["), "]")
+			prefix, err := netip.ParsePrefix(client)
+			if err == nil {
+				m.sourceIPCIDR = append(m.sourceIPCIDR, prefix.String())
+				continue
+			}
+			address, err := netip.ParseAddr(client)
+			if err != nil {
+				return E.New("unsupported client: ", client)
+			}
+			m.sourceIPCIDR = append(m.sourceIPCIDR, address.String())
+		}
+	case "app":
+		for _, app := range values {
+			if strings.Contains(app, "*") {
+				return E.New("wildcard app is not supported: ", app)
+			}
+			// Android package names are reverse domain names, others are process names like `firefox.exe`
+			if strings.Contains(app, ".") && !strings.HasSuffix(strings.ToLower(app), ".exe") {
+				m.packageName = append(m.packageName, app)
+			} else {
+				m.processName = append(m.processName, app)
+			}
+		}
+	case "denyallow":
+		for _, domain := range values {
+			if !M.IsDomainName(domain) {
+				return E.New("invalid domain: ", domain)
+			}
+			m.denyAllow = append(m.denyAllow, domain)
+		}
+	default:
+		return E.New("unsupported modifier: ", name)
+	}
+	return nil
+}
+
+func (m adguardModifiers) isEmpty() bool {
+	return len(m.queryType) == 0 && len(m.sourceIPCIDR) == 0 && len(m.packageName) == 0 && len(m.processName) == 0 && len(m.denyAllow) == 0
+}
+
+func (m adguardModifiers) key() string {
+	return strings.Join([]string{
+		strings.Join(common.Map(m.queryType, option.DNSQueryType.String), "|"),
+		strings.Join(m.sourceIPCIDR, "|"),
+		strings.Join(m.packageName, "|"),
+		strings.Join(m.processName, "|"),
+		strings.Join(m.denyAllow, "|"),
+	}, ",")
+}
+
+func (m adguardModifiers) apply(rule *option.DefaultHeadlessRule) {
+	rule.QueryType = m.queryType
+	rule.SourceIPCIDR = m.sourceIPCIDR
+	rule.PackageName = m.packageName
+	rule.ProcessName = m.processName
+}
+
+// splitApp splits a rule with both package and process names,
+// since sing-box matches package_name and process_name together.
+func (l adguardRuleLine) splitApp() []adguardRuleLine {
+	if len(l.modifiers.packageName) == 0 || len(l.modifiers.processName) == 0 {
+		return []adguardRuleLine{l}
+	}
+	packageLine, processLine := l, l
+	packageLine.modifiers.processName = nil
+	processLine.modifiers.packageName = nil
+	return []adguardRuleLine{packageLine, processLine}
+}
+
+func ToRules(reader io.Reader, acceptExtendedRules bool, report *adapter.ConvertReport) ([]adapter.Rule, error) {
 	scanner := bufio.NewScanner(reader)
-	var (
-		ruleLines    []adguardRuleLine
-		ignoredLines int
-	)
+	var ruleLines []adguardRuleLine
 parseLine:
 	for scanner.Scan() {
 		ruleLine := scanner.Text()
@@ -75,6 +174,7 @@ parseLine:
 			hasEnd      bool
 			isRegexp    bool
 			isImportant bool
+			modifiers   adguardModifiers
 		)
 		if !strings.HasPrefix(ruleLine, "/") && strings.Contains(ruleLine, "$") {
 			params := common.SubstringAfter(ruleLine, "$")
@@ -83,10 +183,10 @@ parseLine:
 				var ignored bool
 				if len(paramParts) > 0 && len(paramParts) <= 2 {
 					switch paramParts[0] {
-					case "app", "network":
-						// maybe support by package_name/process_name
-					case "dnstype":
-						// maybe support by query_type
+					case "dnstype", "client", "app", "denyallow":
+						if len(paramParts) == 2 {
+							ignored = modifiers.parse(paramParts[0], paramParts[1]) == nil
+						}
 					case "important":
 						ignored = true
 						isImportant = true
@@ -97,8 +197,7 @@ parseLine:
 					}
 				}
 				if !ignored {
-					ignoredLines++
-					logger.Debug("ignored unsupported rule with modifier: ", paramParts[0], ": ", originRuleLine)
+					report.Drop("rules with unsupported modifier "+paramParts[0], 1, originRuleLine)
 					continue parseLine
 				}
 			}
@@ -107,6 +206,10 @@ parseLine:
 		if strings.HasPrefix(ruleLine, "@@") {
 			ruleLine = ruleLine[2:]
 			isExclude = true
+			if len(modifiers.denyAllow) > 0 {
+				report.Drop("exception rules with modifier denyallow", 1, originRuleLine)
+				continue
+			}
 		}
 		if strings.HasSuffix(ruleLine, "|") {
 			ruleLine = ruleLine[:len(ruleLine)-1]
@@ -125,8 +228,7 @@ parseLine:
 		if strings.HasPrefix(ruleLine, "/") && strings.HasSuffix(ruleLine, "/") {
 			ruleLine = ruleLine[1 : len(ruleLine)-1]
 			if ignoreIPCIDRRegexp(ruleLine) {
-				ignoredLines++
-				logger.Debug("ignored unsupported rule with IPCIDR regexp: ", originRuleLine)
+				report.Drop("rules with IP CIDR regexp", 1, originRuleLine)
 				continue
 			}
 			isRegexp = true
@@ -136,25 +238,21 @@ parseLine:
 				isSuffix = true
 			}
 			if strings.Contains(ruleLine, "/") {
-				ignoredLines++
-				logger.Debug("ignored unsupported rule with path: ", originRuleLine)
+				report.Drop("rules with path", 1, originRuleLine)
 				continue
 			}
 			if strings.Contains(ruleLine, "?") || strings.Contains(ruleLine, "&") {
-				ignoredLines++
-				logger.Debug("ignored unsupported rule with query: ", originRuleLine)
+				report.Drop("rules with query", 1, originRuleLine)
 				continue
 			}
 			if strings.Contains(ruleLine, "[") || strings.Contains(ruleLine, "]") ||
 				strings.Contains(ruleLine, "(") || strings.Contains(ruleLine, ")") ||
 				strings.Contains(ruleLine, "!") || strings.Contains(ruleLine, "#") {
-				ignoredLines++
-				logger.Debug("ignored unsupported cosmetic filter: ", originRuleLine)
+				report.Drop("cosmetic filters", 1, originRuleLine)
 				continue
 			}
 			if strings.Contains(ruleLine, "~") {
-				ignoredLines++
-				logger.Debug("ignored unsupported rule modifier: ", originRuleLine)
+				report.Drop("rules with modifier", 1, originRuleLine)
 				continue
 			}
 			var domainCheck string
@@ -164,24 +262,21 @@ parseLine:
 				domainCheck = ruleLine
 			}
 			if ruleLine == "" {
-				ignoredLines++
-				logger.Debug("ignored unsupported rule with empty domain", originRuleLine)
+				report.Drop("rules with empty domain", 1, originRuleLine)
 				continue
 			} else {
 				domainCheck = strings.ReplaceAll(domainCheck, "*", "x")
 				if !M.IsDomainName(domainCheck) {
 					_, ipErr := parseADGuardIPCIDRLine(ruleLine)
 					if ipErr == nil {
-						ignoredLines++
-						logger.Debug("ignored unsupported rule with IPCIDR: ", originRuleLine)
+						report.Drop("rules with IP CIDR", 1, originRuleLine)
 						continue
 					}
 					if M.ParseSocksaddr(domainCheck).Port != 0 {
-						logger.Debug("ignored unsupported rule with port: ", originRuleLine)
+						report.Drop("rules with port", 1, originRuleLine)
 					} else {
-						logger.Debug("ignored unsupported rule with invalid domain: ", originRuleLine)
+						report.Drop("rules with invalid domain", 1, originRuleLine)
 					}
-					ignoredLines++
 					continue
 				}
 			}
@@ -195,6 +290,7 @@ parseLine:
 			hasEnd:         hasEnd,
 			isRegexp:       isRegexp,
 			isImportant:    isImportant,
+			modifiers:      modifiers,
 		})
 	}
 	if len(ruleLines) == 0 {
@@ -216,9 +312,16 @@ parseLine:
 			},
 		}, nil
 	}
-	var currentRule adapter.Rule
+	var (
+		mapLine      func(rule *option.DefaultHeadlessRule, it adguardRuleLine)
+		mapDenyAllow func(rule *option.DefaultHeadlessRule, domain string)
+	)
 	if acceptExtendedRules {
-		mapDomain := func(it adguardRuleLine) string {
+		mapLine = func(rule *option.DefaultHeadlessRule, it adguardRuleLine) {
+			if it.isRegexp {
+				rule.DomainRegex = append(rule.DomainRegex, it.ruleLine)
+				return
+			}
 			ruleLine := it.ruleLine
 			if it.isSuffix {
 				ruleLine = "||" + ruleLine
@@ -228,87 +331,10 @@ parseLine:
 			if it.hasEnd {
 				ruleLine += "^"
 			}
-			return ruleLine
-		}
-
-		importantDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && !it.isExclude }), mapDomain)
-		importantDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && it.isRegexp && !it.isExclude }), mapDomain)
-		importantExcludeDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && it.isExclude }), mapDomain)
-		importantExcludeDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && it.isRegexp && it.isExclude }), mapDomain)
-		domain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && !it.isExclude }), mapDomain)
-		domainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && it.isRegexp && !it.isExclude }), mapDomain)
-		excludeDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && it.isExclude }), mapDomain)
-		excludeDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && it.isRegexp && it.isExclude }), mapDomain)
-		currentRule = adapter.Rule{
-			Type: C.RuleTypeDefault,
-			DefaultOptions: adapter.DefaultRule{
-				DefaultHeadlessRule: option.DefaultHeadlessRule{
-					AdGuardDomain: domain,
-					DomainRegex:   domainRegex,
-				},
-			},
-		}
-		if len(excludeDomain) > 0 || len(excludeDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeAnd,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									AdGuardDomain: excludeDomain,
-									DomainRegex:   excludeDomainRegex,
-									Invert:        true,
-								},
-							},
-						},
-						currentRule,
-					},
-				},
-			}
+			rule.AdGuardDomain = append(rule.AdGuardDomain, ruleLine)
 		}
-		if len(importantDomain) > 0 || len(importantDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeOr,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									AdGuardDomain: importantDomain,
-									DomainRegex:   importantDomainRegex,
-								},
-							},
-						},
-						currentRule,
-					},
-				},
-			}
-		}
-		if len(importantExcludeDomain) > 0 || len(importantExcludeDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeAnd,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									AdGuardDomain: importantExcludeDomain,
-									DomainRegex:   importantExcludeDomainRegex,
-									Invert:        true,
-								},
-							},
-						},
-						currentRule,
-					},
-				},
-			}
+		mapDenyAllow = func(rule *option.DefaultHeadlessRule, domain string) {
+			rule.AdGuardDomain = append(rule.AdGuardDomain, "||"+domain+"^")
 		}
 	} else {
 		ruleLines = common.Filter(ruleLines, func(it adguardRuleLine) bool {
@@ -317,116 +343,137 @@ parseLine:
 				originRuleLine = it.ruleLine
 			}
 			if !it.hasEnd {
-				logger.Debug("ignored extended rule without end: ", originRuleLine)
-				ignoredLines++
+				report.Drop("extended rules without end", 1, originRuleLine)
 				return false
 			}
 			if !it.hasStart && !it.isSuffix {
-				logger.Debug("ignored extended rule without start: ", originRuleLine)
-				ignoredLines++
+				report.Drop("extended rules without start", 1, originRuleLine)
 				return false
 			}
 			return true
 		})
-		mapDomain := func(it adguardRuleLine) string { return it.ruleLine }
-		importantDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && !it.isExclude && !it.isSuffix }), mapDomain)
-		importantDomainSuffix := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && !it.isExclude && it.isSuffix }), mapDomain)
-		importantDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && it.isRegexp && !it.isExclude }), mapDomain)
-		importantExcludeDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && it.isExclude && !it.isSuffix }), mapDomain)
-		importantExcludeDomainSuffix := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && !it.isRegexp && it.isExclude && it.isSuffix }), mapDomain)
-		importantExcludeDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return it.isImportant && it.isRegexp && it.isExclude }), mapDomain)
-		domain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && !it.isExclude && !it.isSuffix }), mapDomain)
-		domainSuffix := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && !it.isExclude && it.isSuffix }), mapDomain)
-		domainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && it.isRegexp && !it.isExclude }), mapDomain)
-		excludeDomain := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && it.isExclude && !it.isSuffix }), mapDomain)
-		excludeDomainSuffix := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && !it.isRegexp && it.isExclude && it.isSuffix }), mapDomain)
-		excludeDomainRegex := common.Map(common.Filter(ruleLines, func(it adguardRuleLine) bool { return !it.isImportant && it.isRegexp && it.isExclude }), mapDomain)
-		currentRule = adapter.Rule{
-			Type: C.RuleTypeDefault,
-			DefaultOptions: adapter.DefaultRule{
-				DefaultHeadlessRule: option.DefaultHeadlessRule{
-					Domain:       domain,
-					DomainSuffix: domainSuffix,
-					DomainRegex:  domainRegex,
-				},
-			},
+		mapLine = func(rule *option.DefaultHeadlessRule, it adguardRuleLine) {
+			if it.isRegexp {
+				rule.DomainRegex = append(rule.DomainRegex, it.ruleLine)
+			} else if it.isSuffix {
+				rule.DomainSuffix = append(rule.DomainSuffix, it.ruleLine)
+			} else {
+				rule.Domain = append(rule.Domain, it.ruleLine)
+			}
 		}
-		if len(excludeDomain) > 0 || len(excludeDomainSuffix) > 0 || len(excludeDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeAnd,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									Domain:       excludeDomain,
-									DomainSuffix: excludeDomainSuffix,
-									DomainRegex:  excludeDomainRegex,
-									Invert:       true,
-								},
-							},
-						},
-						currentRule,
-					},
-				},
+		mapDenyAllow = func(rule *option.DefaultHeadlessRule, domain string) {
+			rule.DomainSuffix = append(rule.DomainSuffix, domain)
+		}
+	}
+	buildRules := func(isImportant bool, isExclude bool) []adapter.Rule {
+		var (
+			plainRule     option.DefaultHeadlessRule
+			modifierKeys  []string
+			modifierRules = make(map[string]*option.DefaultHeadlessRule)
+			denyAllow     = make(map[string][]string)
+		)
+		for _, ruleLine := range ruleLines {
+			if ruleLine.isImportant != isImportant || ruleLine.isExclude != isExclude {
+				continue
+			}
+			for _, it := range ruleLine.splitApp() {
+				if it.modifiers.isEmpty() {
+					mapLine(&plainRule, it)
+					continue
+				}
+				key := it.modifiers.key()
+				rule, loaded := modifierRules[key]
+				if !loaded {
+					rule = &option.DefaultHeadlessRule{}
+					it.modifiers.apply(rule)
+					modifierRules[key] = rule
+					modifierKeys = append(modifierKeys, key)
+					denyAllow[key] = it.modifiers.denyAllow
+				}
+				mapLine(rule, it)
 			}
 		}
-		if len(importantDomain) > 0 || len(importantDomainSuffix) > 0 || len(importantDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeOr,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									Domain:       importantDomain,
-									DomainSuffix: importantDomainSuffix,
-									DomainRegex:  importantDomainRegex,
-								},
-							},
-						},
-						currentRule,
-					},
+		var rules []adapter.Rule
+		for _, key := range modifierKeys {
+			modifierRule := *modifierRules[key]
+			modifierRule.Invert = isExclude
+			rule := adapter.Rule{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: adapter.DefaultRule{
+					DefaultHeadlessRule: modifierRule,
 				},
 			}
-		}
-		if len(importantExcludeDomain) > 0 || len(importantExcludeDomainSuffix) > 0 || len(importantExcludeDomainRegex) > 0 {
-			currentRule = adapter.Rule{
-				Type: C.RuleTypeLogical,
-				LogicalOptions: adapter.LogicalRule{
-					Mode: C.LogicalTypeAnd,
-					Rules: []adapter.Rule{
-						{
-							Type: C.RuleTypeDefault,
-							DefaultOptions: adapter.DefaultRule{
-								DefaultHeadlessRule: option.DefaultHeadlessRule{
-									Domain:       importantExcludeDomain,
-									DomainSuffix: importantExcludeDomainSuffix,
-									DomainRegex:  importantExcludeDomainRegex,
-									Invert:       true,
+			if len(denyAllow[key]) > 0 {
+				denyAllowRule := option.DefaultHeadlessRule{Invert: true}
+				for _, domain := range denyAllow[key] {
+					mapDenyAllow(&denyAllowRule, domain)
+				}
+				rule = adapter.Rule{
+					Type: C.RuleTypeLogical,
+					LogicalOptions: adapter.LogicalRule{
+						Mode: C.LogicalTypeAnd,
+						Rules: []adapter.Rule{
+							{
+								Type: C.RuleTypeDefault,
+								DefaultOptions: adapter.DefaultRule{
+									DefaultHeadlessRule: denyAllowRule,
 								},
 							},
+							rule,
 						},
-						currentRule,
 					},
-				},
+				}
 			}
+			rules = append(rules, rule)
+		}
+		if len(plainRule.AdGuardDomain) > 0 || len(plainRule.Domain) > 0 || len(plainRule.DomainSuffix) > 0 || len(plainRule.DomainRegex) > 0 {
+			plainRule.Invert = isExclude
+			rules = append(rules, adapter.Rule{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: adapter.DefaultRule{
+					DefaultHeadlessRule: plainRule,
+				},
+			})
 		}
+		return rules
+	}
+	var currentRules []adapter.Rule
+	if rules := buildRules(false, false); len(rules) > 0 {
+		currentRules = []adapter.Rule{newLogicalRule(C.LogicalTypeOr, rules)}
+		if excludeRules := buildRules(false, true); len(excludeRules) > 0 {
+			currentRules = []adapter.Rule{newLogicalRule(C.LogicalTypeAnd, append(excludeRules, currentRules...))}
+		}
+	}
+	currentRules = append(buildRules(true, false), currentRules...)
+	if len(currentRules) == 0 {
+		return nil, E.New("AdGuard rule-set contains no blocking rules")
 	}
-	if ignoredLines > 0 {
-		logger.Info("parsed rules: ", len(ruleLines), "/", len(ruleLines)+ignoredLines)
+	currentRule := newLogicalRule(C.LogicalTypeOr, currentRules)
+	if importantExcludeRules := buildRules(true, true); len(importantExcludeRules) > 0 {
+		currentRule = newLogicalRule(C.LogicalTypeAnd, append(importantExcludeRules, currentRule))
 	}
 	return []adapter.Rule{currentRule}, nil
 }
 
-func FromRules(rules []adapter.Rule) ([]byte, error) {
+func newLogicalRule(mode string, rules []adapter.Rule) adapter.Rule {
+	if len(rules) == 1 {
+		return rules[0]
+	}
+	return adapter.Rule{
+		Type: C.RuleTypeLogical,
+		LogicalOptions: adapter.LogicalRule{
+			Mode:  mode,
+			Rules: rules,
+		},
+	}
+}
+
+func FromRules(rules []adapter.Rule, report *adapter.ConvertReport) ([]byte, error) {
 	var buffer bytes.Buffer
 	for _, rule := range rules {
-		FromRule(rule, &buffer)
+		if !FromRule(rule, &buffer) {
+			report.Drop("rules unsupported by AdGuard", 1)
+		}
 	}
 	if buffer.Len() > 0 {
 		return buffer.Bytes(), nil
@@ -435,141 +482,181 @@ func FromRules(rules []adapter.Rule) ([]byte, error) {
 	}
 }
 
-func FromRule(rule adapter.Rule, output *bytes.Buffer) {
-	var (
-		importantDomainAdGuard        []string
-		importantDomain               []string
-		importantDomainSuffix         []string
-		importantDomainRegex          []string
-		importantExcludeDomainAdGuard []string
-		importantExcludeDomain        []string
-		importantExcludeDomainSuffix  []string
-		importantExcludeDomainRegex   []string
-		domainAdGuard                 []string
-		domain                        []string
-		domainSuffix                  []string
-		domainRegex                   []string
-		excludeDomainAdGuard          []string
-		excludeDomain                 []string
-		excludeDomainSuffix           []string
-		excludeDomainRegex            []string
-	)
-parse:
-	for {
-		switch rule.Type {
-		case C.RuleTypeLogical:
-			if !(len(rule.LogicalOptions.Rules) == 2 && rule.LogicalOptions.Rules[0].Type == C.RuleTypeDefault && adapter.IsDestinationAddressRule(rule.LogicalOptions.Rules[0].DefaultOptions)) {
-				return
-			}
-			if rule.LogicalOptions.Mode == C.LogicalTypeAnd && rule.LogicalOptions.Rules[0].DefaultOptions.Invert {
-				if len(importantExcludeDomainAdGuard) == 0 && len(importantExcludeDomainRegex) == 0 {
-					importantExcludeDomainAdGuard = rule.LogicalOptions.Rules[0].DefaultOptions.AdGuardDomain
-					importantExcludeDomain = rule.LogicalOptions.Rules[0].DefaultOptions.Domain
-					importantExcludeDomainSuffix = rule.LogicalOptions.Rules[0].DefaultOptions.DomainSuffix
-					importantExcludeDomainRegex = rule.LogicalOptions.Rules[0].DefaultOptions.DomainRegex
-				} else {
-					excludeDomainAdGuard = rule.LogicalOptions.Rules[0].DefaultOptions.AdGuardDomain
-					excludeDomain = rule.LogicalOptions.Rules[0].DefaultOptions.Domain
-					excludeDomainSuffix = rule.LogicalOptions.Rules[0].DefaultOptions.DomainSuffix
-					excludeDomainRegex = rule.LogicalOptions.Rules[0].DefaultOptions.DomainRegex
-				}
-			} else if rule.LogicalOptions.Mode == C.LogicalTypeOr && !rule.LogicalOptions.Rules[0].DefaultOptions.Invert {
-				importantDomainAdGuard = rule.LogicalOptions.Rules[0].DefaultOptions.AdGuardDomain
-				importantDomain = rule.LogicalOptions.Rules[0].DefaultOptions.Domain
-				importantDomainSuffix = rule.LogicalOptions.Rules[0].DefaultOptions.DomainSuffix
-				importantDomainRegex = rule.LogicalOptions.Rules[0].DefaultOptions.DomainRegex
-			} else {
-				return
-			}
-			rule = rule.LogicalOptions.Rules[1]
-		case C.RuleTypeDefault:
-			if !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
-				return
-			}
-			domainAdGuard = rule.DefaultOptions.AdGuardDomain
-			domain = rule.DefaultOptions.Domain
-			domainSuffix = rule.DefaultOptions.DomainSuffix
-			domainRegex = rule.DefaultOptions.DomainRegex
-			break parse
-		}
-	}
-	for _, ruleLine := range importantDomainAdGuard {
-		output.WriteString(ruleLine)
-		output.WriteString("$important\n")
-	}
-	for _, ruleLine := range importantDomain {
-		output.WriteString("|")
-		output.WriteString(ruleLine)
-		output.WriteString("^$important\n")
-	}
-	for _, ruleLine := range importantDomainSuffix {
-		output.WriteString("||")
-		output.WriteString(ruleLine)
-		output.WriteString("^$important\n")
-	}
-	for _, ruleLine := range importantDomainRegex {
-		output.WriteString("/")
-		output.WriteString(ruleLine)
-		output.WriteString("/$important\n")
-	}
-	for _, ruleLine := range importantExcludeDomainAdGuard {
-		output.WriteString("@@")
-		output.WriteString(ruleLine)
-		output.WriteString("$important\n")
-	}
-	for _, ruleLine := range importantExcludeDomain {
-		output.WriteString("@@|")
-		output.WriteString(ruleLine)
-		output.WriteString("^$important\n")
-	}
-	for _, ruleLine := range importantExcludeDomainSuffix {
-		output.WriteString("@@||")
-		output.WriteString(ruleLine)
-		output.WriteString("^$important\n")
-	}
-	for _, ruleLine := range importantExcludeDomainRegex {
-		output.WriteString("@@/")
-		output.WriteString(ruleLine)
-		output.WriteString("/$important\n")
-	}
-	for _, ruleLine := range domainAdGuard {
-		output.WriteString(ruleLine)
-		output.WriteString("\n")
-	}
-	for _, ruleLine := range domain {
-		output.WriteString("|")
-		output.WriteString(ruleLine)
-		output.WriteString("^\n")
-	}
-	for _, ruleLine := range domainSuffix {
-		output.WriteString("||")
-		output.WriteString(ruleLine)
-		output.WriteString("^\n")
-	}
-	for _, ruleLine := range domainRegex {
-		output.WriteString("/")
-		output.WriteString(ruleLine)
-		output.WriteString("/\n")
-	}
-	for _, ruleLine := range excludeDomainAdGuard {
-		output.WriteString("@@")
-		output.WriteString(ruleLine)
-		output.WriteString("\n")
-	}
-	for _, ruleLine := range excludeDomain {
-		output.WriteString("@@|")
-		output.WriteString(ruleLine)
-		output.WriteString("^\n")
-	}
-	for _, ruleLine := range excludeDomainSuffix {
-		output.WriteString("@@||")
-		output.WriteString(ruleLine)
-		output.WriteString("^\n")
-	}
-	for _, ruleLine := range excludeDomainRegex {
-		output.WriteString("@@/")
-		output.WriteString(ruleLine)
-		output.WriteString("/\n")
+const (
+	levelImportantExclude = iota
+	levelImportant
+	levelExclude
+	levelRules
+)
+
+// adguardRuleLevels mirrors the AdGuard priority: important exceptions, important rules, exceptions and rules.
+type adguardRuleLevels struct {
+	importantExclude []adapter.Rule
+	important        []adapter.Rule
+	exclude          []adapter.Rule
+	rules            []adapter.Rule
+}
+
+func FromRule(rule adapter.Rule, output *bytes.Buffer) bool {
+	var levels adguardRuleLevels
+	if !levels.parse(rule, levelImportantExclude) {
+		return false
+	}
+	for _, it := range levels.important {
+		writeRule(it, true, output)
+	}
+	for _, it := range levels.importantExclude {
+		writeRule(it, true, output)
+	}
+	for _, it := range levels.rules {
+		writeRule(it, false, output)
+	}
+	for _, it := range levels.exclude {
+		writeRule(it, false, output)
+	}
+	return true
+}
+
+func (l *adguardRuleLevels) parse(rule adapter.Rule, level int) bool {
+	if rule.Type == C.RuleTypeDefault {
+		if !isAdGuardRule(rule, false) {
+			return false
+		}
+		l.rules = append(l.rules, rule)
+		return true
+	}
+	if rule.Type != C.RuleTypeLogical || rule.LogicalOptions.Invert || len(rule.LogicalOptions.Rules) < 2 {
+		return false
+	}
+	subRules := rule.LogicalOptions.Rules
+	children, inner := subRules[:len(subRules)-1], subRules[len(subRules)-1]
+	switch rule.LogicalOptions.Mode {
+	case C.LogicalTypeAnd:
+		if !common.All(children, func(it adapter.Rule) bool {
+			return isAdGuardRule(it, true)
+		}) {
+			return false
+		}
+		if level <= levelImportantExclude && isImportantLevel(inner) {
+			l.importantExclude = children
+			return l.parse(inner, levelImportant)
+		} else if level <= levelExclude {
+			l.exclude = children
+			return l.parse(inner, levelRules)
+		}
+	case C.LogicalTypeOr:
+		if !common.All(children, func(it adapter.Rule) bool {
+			return isAdGuardRule(it, false)
+		}) {
+			return false
+		}
+		if isAdGuardRule(inner, false) {
+			l.rules = append(l.rules, subRules...)
+			return true
+		} else if level <= levelImportant {
+			l.important = children
+			return l.parse(inner, levelExclude)
+		}
+	}
+	return false
+}
+
+func isImportantLevel(rule adapter.Rule) bool {
+	return rule.Type == C.RuleTypeLogical && rule.LogicalOptions.Mode == C.LogicalTypeOr &&
+		len(rule.LogicalOptions.Rules) > 0 && !isAdGuardRule(rule.LogicalOptions.Rules[len(rule.LogicalOptions.Rules)-1], false)
+}
+
+func isAdGuardRule(rule adapter.Rule, isExclude bool) bool {
+	switch rule.Type {
+	case C.RuleTypeDefault:
+		if rule.DefaultOptions.Invert != isExclude {
+			return false
+		}
+		defaultRule := rule.DefaultOptions
+		defaultRule.AdGuardDomain = nil
+		defaultRule.QueryType = nil
+		defaultRule.SourceIPCIDR = nil
+		defaultRule.PackageName = nil
+		defaultRule.ProcessName = nil
+		defaultRule.Invert = false
+		return adapter.IsDestinationAddressRule(defaultRule)
+	case C.RuleTypeLogical:
+		// $denyallow
+		if isExclude || rule.LogicalOptions.Mode != C.LogicalTypeAnd || rule.LogicalOptions.Invert || len(rule.LogicalOptions.Rules) != 2 {
+			return false
+		}
+		return len(denyAllowDomains(rule.LogicalOptions.Rules[0])) > 0 && rule.LogicalOptions.Rules[1].Type == C.RuleTypeDefault && isAdGuardRule(rule.LogicalOptions.Rules[1], false)
+	default:
+		return false
+	}
+}
+
+func denyAllowDomains(rule adapter.Rule) []string {
+	if rule.Type != C.RuleTypeDefault || !rule.DefaultOptions.Invert {
+		return nil
+	}
+	var defaultRule adapter.DefaultRule
+	defaultRule.AdGuardDomain = rule.DefaultOptions.AdGuardDomain
+	defaultRule.DomainSuffix = rule.DefaultOptions.DomainSuffix
+	defaultRule.Invert = true
+	if !reflect.DeepEqual(rule.DefaultOptions, defaultRule) {
+		return nil
+	}
+	domains := append([]string(nil), rule.DefaultOptions.DomainSuffix...)
+	for _, ruleLine := range rule.DefaultOptions.AdGuardDomain {
+		if !strings.HasPrefix(ruleLine, "||") || !strings.HasSuffix(ruleLine, "^") {
+			return nil
+		}
+		domain := ruleLine[2 : len(ruleLine)-1]
+		if !M.IsDomainName(domain) {
+			return nil
+		}
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+func writeRule(rule adapter.Rule, isImportant bool, output *bytes.Buffer) {
+	var denyAllow []string
+	if rule.Type == C.RuleTypeLogical {
+		denyAllow = denyAllowDomains(rule.LogicalOptions.Rules[0])
+		rule = rule.LogicalOptions.Rules[1]
+	}
+	options := rule.DefaultOptions
+	var modifiers []string
+	if isImportant {
+		modifiers = append(modifiers, "important")
+	}
+	if len(options.QueryType) > 0 {
+		modifiers = append(modifiers, "dnstype="+strings.Join(common.Map(options.QueryType, option.DNSQueryType.String), "|"))
+	}
+	if len(options.SourceIPCIDR) > 0 {
+		modifiers = append(modifiers, "client="+strings.Join(options.SourceIPCIDR, "|"))
+	}
+	if len(options.PackageName) > 0 || len(options.ProcessName) > 0 {
+		modifiers = append(modifiers, "app="+strings.Join(append(append([]string(nil), options.PackageName...), options.ProcessName...), "|"))
+	}
+	if len(denyAllow) > 0 {
+		modifiers = append(modifiers, "denyallow="+strings.Join(denyAllow, "|"))
+	}
+	var prefix, suffix string
+	if options.Invert {
+		prefix = "@@"
+	}
+	if len(modifiers) > 0 {
+		suffix = "$" + strings.Join(modifiers, ",")
+	}
+	for _, ruleLine := range options.AdGuardDomain {
+		output.WriteString(prefix + ruleLine + suffix + "\n")
+	}
+	for _, ruleLine := range options.Domain {
+		output.WriteString(prefix + "|" + ruleLine + "^" + suffix + "\n")
+	}
+	for _, ruleLine := range options.DomainSuffix {
+		output.WriteString(prefix + "||" + ruleLine + "^" + suffix + "\n")
+	}
+	for _, ruleLine := range options.DomainRegex {
+		output.WriteString(prefix + "/" + ruleLine + "/" + suffix + "\n")
 	}
 }
 
@@ -7,7 +7,8 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/route/rule"
-	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	S "github.com/sagernet/srsc/adapter"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +26,7 @@ example.net^
 example.arpa
 @@|sagernet.example.org^
 `
-	rules, err := ToRules(strings.NewReader(ruleString), true, logger.NOP())
+	rules, err := ToRules(strings.NewReader(ruleString), true, nil)
 	require.NoError(t, err)
 	require.Len(t, rules, 1)
 	rule, err := rule.NewHeadlessRule(context.Background(), rules[0].ToHeadless())
@@ -76,7 +77,7 @@ example.arpa
 			Domain: domain,
 		}), domain)
 	}
-	ruleFromOptions, err := FromRules(rules)
+	ruleFromOptions, err := FromRules(rules, nil)
 	require.NoError(t, err)
 	require.Equal(t, ruleString, string(ruleFromOptions))
 }
@@ -87,7 +88,7 @@ func TestHosts(t *testing.T) {
 127.0.0.1 localhost
 ::1 localhost #[IPv6]
 0.0.0.0 google.com
-`), true, logger.NOP())
+`), true, nil)
 	require.NoError(t, err)
 	require.Len(t, rules, 1)
 	rule, err := rule.NewHeadlessRule(context.Background(), rules[0].ToHeadless())
@@ -117,7 +118,7 @@ func TestSimpleHosts(t *testing.T) {
 	rules, err := ToRules(strings.NewReader(`
 example.com
 www.example.org
-`), true, logger.NOP())
+`), true, nil)
 	require.NoError(t, err)
 	require.Len(t, rules, 1)
 	rule, err := rule.NewHeadlessRule(context.Background(), rules[0].ToHeadless())
@@ -143,3 +144,50 @@ www.example.org
 		}), domain)
 	}
 }
+
+func TestModifiers(t *testing.T) {
+	t.Parallel()
+	ruleString := `||example.org^$dnstype=AAAA
+||example.com^$client=192.168.1.0/24
+||example.net^$app=com.example.app
+||sagernet.org^$denyallow=sing-box.sagernet.org
+@@||www.example.org^$dnstype=AAAA
+`
+	rules, err := ToRules(strings.NewReader(ruleString), false, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	rule, err := rule.NewHeadlessRule(context.Background(), rules[0].ToHeadless())
+	require.NoError(t, err)
+	matchMetadata := []adapter.InboundContext{
+		{Domain: "example.com", Source: M.ParseSocksaddr("192.168.1.1:53")},
+		{Domain: "sagernet.org"},
+		{Domain: "www.sagernet.org"},
+	}
+	notMatchMetadata := []adapter.InboundContext{
+		{Domain: "example.com", Source: M.ParseSocksaddr("192.168.2.1:53")},
+		{Domain: "example.net"},
+		{Domain: "sing-box.sagernet.org"},
+	}
+	for _, metadata := range matchMetadata {
+		require.True(t, rule.Match(&metadata), metadata.Domain)
+	}
+	for _, metadata := range notMatchMetadata {
+		require.False(t, rule.Match(&metadata), metadata.Domain)
+	}
+	ruleFromOptions, err := FromRules(rules, nil)
+	require.NoError(t, err)
+	require.Equal(t, ruleString, string(ruleFromOptions))
+}
+
+func TestUnsupportedModifiers(t *testing.T) {
+	t.Parallel()
+	var report S.ConvertReport
+	rules, err := ToRules(strings.NewReader(`||example.org^$client='Frank'
+||example.com^$dnstype=~A
+||example.net^
+`), false, &report)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"example.net"}, []string(rules[0].DefaultOptions.DomainSuffix))
+	require.Equal(t, 2, report.Dropped())
+}
@@ -0,0 +1,53 @@
+package convertor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*AdGuardHome)(nil)
+
+type AdGuardHome struct{}
+
+func (a *AdGuardHome) Type() string {
+	return C.ConvertorTypeAdGuardHome
+}
+
+func (a *AdGuardHome) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (a *AdGuardHome) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return nil, E.New("AdGuard Home upstream configuration can only be used as target")
+}
+
+func (a *AdGuardHome) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	upstream := options.Options.TargetConvertOptions.AdGuardHomeOptions.Upstream
+	if len(upstream) == 0 {
+		return nil, E.New("missing upstream in AdGuard Home options")
+	}
+	domains, dropped, err := collectDNSDomains(ctx, contentRules, "AdGuard Home", options.Report, true, true)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writeDroppedComments(&output, dropped)
+	for _, domain := range domains {
+		// AdGuard Home matches subdomains only with the "*." prefix,
+		// subdomains of exact domains are sent to default upstreams by "#".
+		if domain.Subdomain {
+			output.WriteString("[/*." + domain.Name + "/]" + strings.Join(upstream, " ") + "\n")
+			continue
+		}
+		output.WriteString("[/" + domain.Name + "/]" + strings.Join(upstream, " ") + "\n")
+		if domain.Exact {
+			output.WriteString("[/*." + domain.Name + "/]#\n")
+		}
+	}
+	return output.Bytes(), nil
+}
@@ -40,13 +40,15 @@ func (s *RuleSetBinary) To(ctx context.Context, contentRules []adapter.Rule, opt
 	ruleSet := &option.PlainRuleSetCompat{
 		Version: boxConstant.RuleSetVersionCurrent,
 		Options: option.PlainRuleSet{
-			Rules: common.Map(common.Filter(convertedRules, func(it adapter.Rule) bool {
-				return it.Headlessable()
-			}), adapter.Rule.ToHeadless),
+			Rules: toHeadlessRules(convertedRules, options.Report),
 		},
 	}
-	if options.Metadata.Platform == C.PlatformSingBox && options.Metadata.Version != nil {
-		Downgrade(ruleSet, options.Metadata.Version)
+	version, err := TargetVersion(options)
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		Downgrade(ruleSet, version, options.Report)
 	}
 	buffer := new(bytes.Buffer)
 	err = srs.Write(buffer, ruleSet.Options, ruleSet.Version)
@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"net/url"
+	"path"
 	"reflect"
 	"strings"
 
 	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/srsc/adapter"
 	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
 
 	"gopkg.in/yaml.v3"
 )
@@ -49,7 +53,7 @@ func (c *RuleProvider) From(ctx context.Context, content []byte, options adapter
 		}
 		lines = ruleProvider.Payload
 	case "mrs":
-		return fromMrs(content)
+		return fromMrs(content, options.Report)
 	case "":
 		return nil, E.New("missing source format in options")
 	default:
@@ -61,12 +65,12 @@ func (c *RuleProvider) From(ctx context.Context, content []byte, options adapter
 		var rule adapter.DefaultRule
 		if len(lines) > 0 {
 			for _, line := range lines {
-				fromDomainLine(&rule, line)
+				fromDomainLine(&rule, line, options.Report)
 			}
 		} else {
 			scanner := bufio.NewScanner(bytes.NewReader(content))
 			for scanner.Scan() {
-				fromDomainLine(&rule, scanner.Text())
+				fromDomainLine(&rule, scanner.Text(), options.Report)
 			}
 		}
 		return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
@@ -85,23 +89,28 @@ func (c *RuleProvider) From(ctx context.Context, content []byte, options adapter
 		return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
 	case "classical":
 		var rules []adapter.Rule
+		parseLine := func(line string) {
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				return
+			}
+			rule, err := fromClassicalLine(line)
+			if err != nil {
+				options.Report.Drop("rules unsupported by sing-box", 1, line)
+				return
+			}
+			rules = append(rules, *rule)
+		}
 		if len(lines) > 0 {
 			for _, line := range lines {
-				rule, _ := fromClassicalLine(line)
-				if rule != nil {
-					rules = append(rules, *rule)
-				}
+				parseLine(line)
 			}
 		} else {
 			scanner := bufio.NewScanner(bytes.NewReader(content))
 			for scanner.Scan() {
-				rule, _ := fromClassicalLine(scanner.Text())
-				if rule != nil {
-					rules = append(rules, *rule)
-				}
+				parseLine(scanner.Text())
 			}
 		}
-		return adapter.MergeRules(rules), nil
+		return adapter.ResolveRuleSets(ctx, adapter.MergeRules(rules), ruleSetURLOptions)
 	case "":
 		return nil, E.New("missing source behavior in options")
 	default:
@@ -117,9 +126,9 @@ func (c *RuleProvider) To(ctx context.Context, contentRules []adapter.Rule, opti
 	format := options.Options.TargetConvertOptions.ClashOptions.TargetFormat
 	behavior := options.Options.TargetConvertOptions.ClashOptions.TargetBehavior
 	if format == "mrs" {
-		return toMrs(behavior, convertedRules)
+		return toMrs(behavior, convertedRules, options.Report)
 	}
-	ruleLines, err := toLines(behavior, convertedRules)
+	ruleLines, err := toLines(behavior, convertedRules, options.Metadata, options.Report)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +160,7 @@ func (c *RuleProvider) To(ctx context.Context, contentRules []adapter.Rule, opti
 	}
 }
 
-func fromDomainLine(rule *adapter.DefaultRule, ruleLine string) {
+func fromDomainLine(rule *adapter.DefaultRule, ruleLine string, report *adapter.ConvertReport) {
 	if ruleLine == "" || strings.HasPrefix(ruleLine, "#") {
 		return
 	}
@@ -161,6 +170,7 @@ func fromDomainLine(rule *adapter.DefaultRule, ruleLine string) {
 		ruleLine = strings.TrimPrefix(ruleLine, "+.")
 	}
 	if strings.Contains(ruleLine, "+") || strings.Contains(ruleLine, "*") {
+		report.Drop("domain wildcards unsupported by sing-box", 1, ruleLine)
 		return
 	}
 	if domainSuffix {
@@ -177,14 +187,34 @@ func fromIPCIDRLine(rule *adapter.DefaultRule, ruleLine string) {
 	rule.IPCIDR = append(rule.IPCIDR, ruleLine)
 }
 
-func toLines(behavior string, rules []adapter.Rule) ([]string, error) {
+// premiumRuleTypes lists rule types supported by Clash Premium, other rule types are only supported by mihomo.
+var premiumRuleTypes = map[string]bool{
+	"DOMAIN":         true,
+	"DOMAIN-SUFFIX":  true,
+	"DOMAIN-KEYWORD": true,
+	"GEOIP":          true,
+	"IP-CIDR":        true,
+	"IP-CIDR6":       true,
+	"SRC-IP-CIDR":    true,
+	"SRC-PORT":       true,
+	"DST-PORT":       true,
+	"PROCESS-NAME":   true,
+	"PROCESS-PATH":   true,
+	"RULE-SET":       true,
+}
+
+// toLines returns lines of the behavior, rules of classical behavior are limited to types supported by Clash Premium
+// for Clash clients, while mihomo and unknown clients get all rule types.
+func toLines(behavior string, rules []adapter.Rule, metadata C.Metadata, report *adapter.ConvertReport) ([]string, error) {
 	var lines []string
 	switch behavior {
 	case "domain":
 		for _, rule := range rules {
 			if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+				report.DropRule(rule, "Clash domain behavior")
 				continue
 			}
+			report.DropDestinationItems(rule.DefaultOptions, "Clash domain behavior", "domain", "domain_suffix")
 			for _, domain := range rule.DefaultOptions.Domain {
 				lines = append(lines, domain)
 			}
@@ -196,18 +226,36 @@ func toLines(behavior string, rules []adapter.Rule) ([]string, error) {
 	case "ipcidr":
 		for _, rule := range rules {
 			if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+				report.DropRule(rule, "Clash ipcidr behavior")
 				continue
 			}
+			report.DropDestinationItems(rule.DefaultOptions, "Clash ipcidr behavior", "ip_cidr")
 			for _, ipCidr := range rule.DefaultOptions.IPCIDR {
 				lines = append(lines, ipCidr)
 			}
 		}
 	case "classical":
 		for _, rule := range rules {
+			// inverted rules are written as NOT rules.
+			if metadata.Platform == C.PlatformClash && (rule.Type == boxConstant.RuleTypeLogical || rule.DefaultOptions.Invert) {
+				report.Drop("logical rules unsupported by Clash Premium", 1)
+				continue
+			}
 			ruleLines, err := toClassicalLine(rule)
-			if err == nil {
+			if err != nil {
+				report.Drop("rules unsupported by Clash", 1, err.Error())
 				continue
 			}
+			if metadata.Platform == C.PlatformClash {
+				ruleLines = common.Filter(ruleLines, func(line string) bool {
+					ruleType, _, _ := strings.Cut(line, ",")
+					if !premiumRuleTypes[ruleType] {
+						report.Drop("rules of mihomo-only types unsupported by Clash Premium", 1, line)
+						return false
+					}
+					return true
+				})
+			}
 			lines = append(lines, ruleLines...)
 		}
 	}
@@ -220,3 +268,29 @@ func IsSimpleDomainRule(rule adapter.DefaultRule) bool {
 	defaultRule.DomainSuffix = rule.DomainSuffix
 	return reflect.DeepEqual(rule, defaultRule)
 }
+
+// ruleSetURLOptions detects the format of referenced rule-set URLs by the file extension.
+func ruleSetURLOptions(reference string, domainSet bool) option.SourceConvertOptions {
+	if domainSet {
+		return option.SourceConvertOptions{
+			SourceType:   C.ConvertorTypeSurgeRuleSet,
+			SurgeOptions: option.SurgeRuleProviderSourceOptions{SourceBehavior: "domain"},
+		}
+	}
+	format := "text"
+	if referenceURL, err := url.Parse(reference); err == nil {
+		switch path.Ext(referenceURL.Path) {
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".mrs":
+			format = "mrs"
+		}
+	}
+	return option.SourceConvertOptions{
+		SourceType: C.ConvertorTypeClashRuleProvider,
+		ClashOptions: option.ClashRuleProviderSourceOptions{
+			SourceFormat:   format,
+			SourceBehavior: "classical",
+		},
+	}
+}
@@ -143,15 +143,29 @@ func toClassicalLine(rule adapter.Rule) ([]string, error) {
 			lines = append(lines, "IN-TYPE,"+inboundType)
 		}
 		if len(rule.DefaultOptions.InboundPort) > 0 {
-			var portRangeList []string
-			for _, portRange := range rule.DefaultOptions.InboundPort {
-				portRangeList = append(portRangeList, F.ToString(portRange.Start, ":", portRange.End))
-			}
-			lines = append(lines, "IN-PORT,"+strings.Join(portRangeList, "/"))
+			lines = append(lines, "IN-PORT,"+formatRanges(rule.DefaultOptions.InboundPort))
 		}
 		for _, inboundUser := range rule.DefaultOptions.InboundUser {
 			lines = append(lines, "IN-USER,"+inboundUser)
 		}
+		for _, ipSuffix := range rule.DefaultOptions.IPSuffix {
+			lines = append(lines, "IP-SUFFIX,"+ipSuffix)
+		}
+		for _, sourceIPSuffix := range rule.DefaultOptions.SourceIPSuffix {
+			lines = append(lines, "SRC-IP-SUFFIX,"+sourceIPSuffix)
+		}
+		if len(rule.DefaultOptions.DSCP) > 0 {
+			lines = append(lines, "DSCP,"+formatRanges(rule.DefaultOptions.DSCP))
+		}
+		if len(rule.DefaultOptions.UID) > 0 {
+			lines = append(lines, "UID,"+formatRanges(rule.DefaultOptions.UID))
+		}
+		for _, ruleSet := range rule.DefaultOptions.RuleSet {
+			lines = append(lines, "RULE-SET,"+ruleSet)
+		}
+		for _, domainSet := range rule.DefaultOptions.DomainSet {
+			lines = append(lines, "RULE-SET,"+domainSet)
+		}
 		return lines, nil
 	}
 }
@@ -160,8 +174,10 @@ func fromClassicalLine(ruleLine string) (*adapter.Rule, error) {
 	ruleType, payload, params := parseRule(ruleLine)
 	var rule adapter.DefaultRule
 	switch ruleType {
-	case "MATCH", "RULE-SET", "SUB-RULE":
+	case "MATCH", "SUB-RULE":
 		return nil, E.New("unsupported rule type on classical rule-set: ", ruleType)
+	case "RULE-SET":
+		rule.RuleSet = append(rule.RuleSet, payload)
 	case "DOMAIN":
 		rule.Domain = append(rule.Domain, payload)
 	case "DOMAIN-SUFFIX":
@@ -181,11 +197,11 @@ func fromClassicalLine(ruleLine string) (*adapter.Rule, error) {
 		rule.SourceIPCIDR = append(rule.SourceIPCIDR, payload)
 	case "SRC-PORT":
 		portRanges, err := utils.NewUnsignedRanges[uint16](payload)
-		if err == nil {
+		if err != nil {
 			return nil, err
 		}
 		for _, portRange := range portRanges {
-			if portRanges[0].Start() == portRanges[0].End() {
+			if portRange.Start() == portRange.End() {
 				rule.SourcePort = append(rule.SourcePort, portRange.Start())
 			} else {
 				rule.SourcePortRange = append(rule.SourcePortRange, F.ToString(portRange.Start(), ":", portRange.End()))
@@ -193,23 +209,35 @@ func fromClassicalLine(ruleLine string) (*adapter.Rule, error) {
 		}
 	case "DST-PORT":
 		portRanges, err := utils.NewUnsignedRanges[uint16](payload)
-		if err == nil {
+		if err != nil {
 			return nil, err
 		}
 		for _, portRange := range portRanges {
-			if portRanges[0].Start() == portRanges[0].End() {
+			if portRange.Start() == portRange.End() {
 				rule.Port = append(rule.Port, portRange.Start())
 			} else {
 				rule.PortRange = append(rule.PortRange, F.ToString(portRange.Start(), ":", portRange.End()))
 			}
 		}
+	case "DOMAIN-WILDCARD":
+		rule.DomainRegex = append(rule.DomainRegex, "^"+wildcardToRegex(payload)+"$")
 	case "PROCESS-NAME":
 		// TODO: maybe android package name here
 		rule.ProcessName = append(rule.ProcessName, payload)
+	case "PROCESS-NAME-REGEX":
+		// match the process name as the last element of process path
+		if strings.HasPrefix(payload, "^") {
+			payload = processNamePrefix + payload[1:]
+		}
+		rule.ProcessPathRegex = append(rule.ProcessPathRegex, payload)
+	case "PROCESS-NAME-WILDCARD":
+		rule.ProcessPathRegex = append(rule.ProcessPathRegex, processNamePrefix+wildcardToRegex(payload)+"$")
 	case "PROCESS-PATH":
 		rule.ProcessPath = append(rule.ProcessPath, payload)
 	case "PROCESS-PATH-REGEX":
 		rule.ProcessPathRegex = append(rule.ProcessPathRegex, payload)
+	case "PROCESS-PATH-WILDCARD":
+		rule.ProcessPathRegex = append(rule.ProcessPathRegex, "^"+wildcardToRegex(payload)+"$")
 	case "NETWORK":
 		switch strings.ToLower(payload) {
 		case N.NetworkTCP:
@@ -230,19 +258,42 @@ func fromClassicalLine(ruleLine string) (*adapter.Rule, error) {
 	case "GEOSITE":
 		rule.GEOSite = append(rule.GEOSite, payload)
 	case "IN-NAME":
-		rule.Inbound = append(rule.Inbound, payload)
+		rule.Inbound = append(rule.Inbound, strings.Split(payload, "/")...)
 	case "IN-TYPE":
-		rule.InboundType = append(rule.InboundType, payload)
+		rule.InboundType = append(rule.InboundType, strings.Split(payload, "/")...)
 	case "IN-PORT":
 		portRanges, err := utils.NewUnsignedRanges[uint16](payload)
-		if err == nil {
+		if err != nil {
 			return nil, err
 		}
 		for _, portRange := range portRanges {
 			rule.InboundPort = append(rule.InboundPort, ranges.New(portRange.Start(), portRange.End()))
 		}
 	case "IN-USER":
-		rule.InboundUser = append(rule.InboundUser, payload)
+		rule.InboundUser = append(rule.InboundUser, strings.Split(payload, "/")...)
+	case "IP-SUFFIX":
+		rule.IPSuffix = append(rule.IPSuffix, payload)
+	case "SRC-IP-SUFFIX":
+		rule.SourceIPSuffix = append(rule.SourceIPSuffix, payload)
+	case "DSCP":
+		dscpRanges, err := utils.NewUnsignedRanges[uint8](payload)
+		if err != nil {
+			return nil, err
+		}
+		for _, dscpRange := range dscpRanges {
+			if dscpRange.End() > 63 {
+				return nil, E.New("invalid DSCP: ", payload)
+			}
+			rule.DSCP = append(rule.DSCP, ranges.New(dscpRange.Start(), dscpRange.End()))
+		}
+	case "UID":
+		uidRanges, err := utils.NewUnsignedRanges[uint32](payload)
+		if err != nil {
+			return nil, err
+		}
+		for _, uidRange := range uidRanges {
+			rule.UID = append(rule.UID, ranges.New(uidRange.Start(), uidRange.End()))
+		}
 	case "AND", "OR", "NOT":
 		return parseLogicLine(ruleType, payload, fromClassicalLine)
 	default:
@@ -263,6 +314,9 @@ func parseRule(ruleRaw string) (string, string, []string) {
 	} else if len(item) > 2 {
 		if item[0] == "NOT" || item[0] == "OR" || item[0] == "AND" || item[0] == "SUB-RULE" || item[0] == "DOMAIN-REGEX" || item[0] == "PROCESS-NAME-REGEX" || item[0] == "PROCESS-PATH-REGEX" {
 			return item[0], strings.Join(item[1:], ","), nil
+		} else if item[0] == "SRC-PORT" || item[0] == "DST-PORT" || item[0] == "IN-PORT" || item[0] == "DSCP" || item[0] == "UID" {
+			// ranges separated by commas, e.g. `DST-PORT,80,443,8000-9000`
+			return item[0], strings.Join(item[1:], "/"), nil
 		} else {
 			return item[0], item[1], item[2:]
 		}
@@ -405,3 +459,31 @@ func convertPortRangeList(rangeList []string) ([]string, error) {
 		return F.ToString(it.Start, "-", it.End)
 	}), nil
 }
+
+// processNamePrefix matches the start of the process name in a process path.
+const processNamePrefix = `(?:^|[/\\])`
+
+// wildcardToRegex converts a mihomo wildcard pattern, `*` matches any characters and `?` matches a single character.
+func wildcardToRegex(pattern string) string {
+	var builder strings.Builder
+	for _, char := range pattern {
+		switch char {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	return builder.String()
+}
+
+func formatRanges[T uint8 | uint16 | uint32](rangeList []ranges.Range[T]) string {
+	return strings.Join(common.Map(rangeList, func(it ranges.Range[T]) string {
+		if it.Start == it.End {
+			return F.ToString(it.Start)
+		}
+		return F.ToString(it.Start, "-", it.End)
+	}), "/")
+}
@@ -0,0 +1,49 @@
+package clash
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/common/ranges"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassicalLine(t *testing.T) {
+	t.Parallel()
+	rule, err := fromClassicalLine("DST-PORT,80,443,8000-9000")
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[uint16]{80, 443}, rule.DefaultOptions.Port)
+	require.Equal(t, badoption.Listable[string]{"8000:9000"}, rule.DefaultOptions.PortRange)
+	rule, err = fromClassicalLine("SRC-PORT,1000-2000/3000")
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[uint16]{3000}, rule.DefaultOptions.SourcePort)
+	require.Equal(t, badoption.Listable[string]{"1000:2000"}, rule.DefaultOptions.SourcePortRange)
+	_, err = fromClassicalLine("DST-PORT,invalid")
+	require.Error(t, err)
+	rule, err = fromClassicalLine("DOMAIN-WILDCARD,*.exam?le.com")
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[string]{`^.*\.exam.le\.com$`}, rule.DefaultOptions.DomainRegex)
+	rule, err = fromClassicalLine("PROCESS-NAME-REGEX,^chrome.*")
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[string]{`(?:^|[/\\])chrome.*`}, rule.DefaultOptions.ProcessPathRegex)
+	rule, err = fromClassicalLine("IN-TYPE,SOCKS/HTTP")
+	require.NoError(t, err)
+	require.Equal(t, []string{"SOCKS", "HTTP"}, rule.DefaultOptions.InboundType)
+	rule, err = fromClassicalLine("GEOSITE,google@cn")
+	require.NoError(t, err)
+	require.Equal(t, []string{"google@cn"}, rule.DefaultOptions.GEOSite)
+	rule, err = fromClassicalLine("UID,1000-1999,0")
+	require.NoError(t, err)
+	require.Equal(t, []ranges.Range[uint32]{ranges.New[uint32](1000, 1999), ranges.New[uint32](0, 0)}, rule.DefaultOptions.UID)
+	lines, err := toClassicalLine(*rule)
+	require.NoError(t, err)
+	require.Equal(t, []string{"UID,1000-1999/0"}, lines)
+	rule, err = fromClassicalLine("IN-PORT,7890/7891-7892")
+	require.NoError(t, err)
+	lines, err = toClassicalLine(*rule)
+	require.NoError(t, err)
+	require.Equal(t, []string{"IN-PORT,7890/7891-7892"}, lines)
+	_, err = fromClassicalLine("DSCP,64")
+	require.Error(t, err)
+}
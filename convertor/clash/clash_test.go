@@ -0,0 +1,61 @@
+package clash
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleProviderPlatform(t *testing.T) {
+	t.Parallel()
+	rules := []adapter.Rule{
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRule{
+				DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+					Domain:      []string{"example.com"},
+					DomainRegex: []string{`^ads\.`},
+				},
+				IPSuffix: []string{"8.8.8.8/24"},
+			},
+		},
+		{
+			Type: boxConstant.RuleTypeLogical,
+			LogicalOptions: adapter.LogicalRule{
+				Mode: boxConstant.LogicalTypeAnd,
+				Rules: []adapter.Rule{{
+					Type: boxConstant.RuleTypeDefault,
+					DefaultOptions: adapter.DefaultRule{
+						DefaultHeadlessRule: boxOption.DefaultHeadlessRule{Domain: []string{"example.org"}},
+					},
+				}},
+			},
+		},
+	}
+	convert := func(metadata C.Metadata, report *adapter.ConvertReport) string {
+		content, err := (*RuleProvider)(nil).To(context.Background(), rules, adapter.ConvertOptions{
+			Options: option.ConvertOptions{TargetConvertOptions: option.TargetConvertOptions{
+				ClashOptions: option.ClashRuleProviderTargetOptions{TargetFormat: "text", TargetBehavior: "classical"},
+			}},
+			Metadata: metadata,
+			Report:   report,
+		})
+		require.NoError(t, err)
+		return string(content)
+	}
+	var report adapter.ConvertReport
+	require.Contains(t, convert(C.DetectMetadata("mihomo/1.19.0"), &report), "DOMAIN,example.com\nDOMAIN-REGEX,^ads\\.\nIP-SUFFIX,8.8.8.8/24\nAND,(")
+	require.Zero(t, report.Dropped())
+	require.Equal(t, "DOMAIN,example.com\n", convert(C.DetectMetadata("ClashX/1.118.0"), &report))
+	require.Equal(t, []adapter.ConvertReportItem{
+		{Reason: "rules of mihomo-only types unsupported by Clash Premium", Count: 2, Samples: []string{"DOMAIN-REGEX,^ads\\.", "IP-SUFFIX,8.8.8.8/24"}},
+		{Reason: "logical rules unsupported by Clash Premium", Count: 1},
+	}, report.Items())
+}
@@ -23,7 +23,7 @@ import (
 
 var MrsMagicBytes = [4]byte{'M', 'R', 'S', 1} // MRSv1
 
-func fromMrs(content []byte) ([]adapter.Rule, error) {
+func fromMrs(content []byte, report *adapter.ConvertReport) ([]adapter.Rule, error) {
 	decoder, err := zstd.NewReader(bytes.NewReader(content))
 	if err != nil {
 		return nil, err
@@ -77,6 +77,7 @@ func fromMrs(content []byte) ([]adapter.Rule, error) {
 				rule.DomainSuffix = append(rule.DomainSuffix, strings.TrimPrefix(key, "+."))
 			} else {
 				if strings.Contains(key, "+") || strings.Contains(key, "*") {
+					report.Drop("domain wildcards unsupported by sing-box", 1, key)
 					continue
 				}
 				rule.Domain = append(rule.Domain, key)
@@ -102,7 +103,7 @@ func fromMrs(content []byte) ([]adapter.Rule, error) {
 	}
 }
 
-func toMrs(behavior string, rules []adapter.Rule) ([]byte, error) {
+func toMrs(behavior string, rules []adapter.Rule, report *adapter.ConvertReport) ([]byte, error) {
 	var output bytes.Buffer
 	encoder, err := zstd.NewWriter(&output, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
 	if err != nil {
@@ -115,11 +116,14 @@ func toMrs(behavior string, rules []adapter.Rule) ([]byte, error) {
 	var ruleSize int64
 	for _, rule := range rules {
 		if rule.Type != C.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			report.DropRule(rule, "Clash mrs")
 			continue
 		}
 		if behavior == "domain" {
+			report.DropDestinationItems(rule.DefaultOptions, "Clash mrs domain behavior", "domain", "domain_suffix")
 			ruleSize += int64(len(rule.DefaultOptions.Domain) + len(rule.DefaultOptions.DomainSuffix))
 		} else {
+			report.DropDestinationItems(rule.DefaultOptions, "Clash mrs ipcidr behavior", "ip_cidr")
 			ruleSize += int64(len(rule.DefaultOptions.IPCIDR))
 		}
 	}
@@ -55,7 +55,11 @@ func ToSurgeLines(rule adapter.Rule) ([]string, error) {
 		len(rule.DefaultOptions.SourceIPASN) > 0 ||
 		len(rule.DefaultOptions.Inbound) > 0 ||
 		len(rule.DefaultOptions.InboundType) > 0 ||
-		len(rule.DefaultOptions.InboundUser) > 0 {
+		len(rule.DefaultOptions.InboundUser) > 0 ||
+		len(rule.DefaultOptions.IPSuffix) > 0 ||
+		len(rule.DefaultOptions.SourceIPSuffix) > 0 ||
+		len(rule.DefaultOptions.DSCP) > 0 ||
+		len(rule.DefaultOptions.UID) > 0 {
 		return nil, E.New("The rule contains options that Surge does not support")
 	} else {
 		var lines []string
@@ -151,6 +155,12 @@ func ToSurgeLines(rule adapter.Rule) ([]string, error) {
 		for _, ipasn := range rule.DefaultOptions.IPASN {
 			lines = append(lines, "IP-ASN,"+ipasn)
 		}
+		for _, ruleSet := range rule.DefaultOptions.RuleSet {
+			lines = append(lines, "RULE-SET,"+ruleSet)
+		}
+		for _, domainSet := range rule.DefaultOptions.DomainSet {
+			lines = append(lines, "DOMAIN-SET,"+domainSet)
+		}
 		return lines, nil
 	}
 }
@@ -225,6 +235,10 @@ func FromSurgeLine(ruleLine string) (*adapter.Rule, error) {
 		boxRule.GEOIP = append(boxRule.GEOIP, payload)
 	case "IP-ASN":
 		boxRule.IPASN = append(boxRule.IPASN, payload)
+	case "RULE-SET":
+		boxRule.RuleSet = append(boxRule.RuleSet, payload)
+	case "DOMAIN-SET":
+		boxRule.DomainSet = append(boxRule.DomainSet, payload)
 	case "AND", "OR", "NOT":
 		return parseLogicLine(ruleType, payload, FromSurgeLine)
 	default:
@@ -5,6 +5,9 @@ import (
 	C "github.com/sagernet/srsc/constant"
 	"github.com/sagernet/srsc/convertor/adguard"
 	"github.com/sagernet/srsc/convertor/clash"
+	"github.com/sagernet/srsc/convertor/mmdb"
+	"github.com/sagernet/srsc/convertor/singbox"
+	"github.com/sagernet/srsc/convertor/v2ray"
 )
 
 var Convertors = map[string]adapter.Convertor{
@@ -13,4 +16,16 @@ var Convertors = map[string]adapter.Convertor{
 	C.ConvertorTypeAdGuardRuleSet:    (*adguard.RuleSet)(nil),
 	C.ConvertorTypeClashRuleProvider: (*clash.RuleProvider)(nil),
 	C.ConvertorTypeSurgeRuleSet:      (*SurgeRuleSet)(nil),
+	C.ConvertorTypeHosts:             (*Hosts)(nil),
+	C.ConvertorTypeDomainList:        (*DomainList)(nil),
+	C.ConvertorTypeIPList:            (*IPList)(nil),
+	C.ConvertorTypeDnsmasq:           (*Dnsmasq)(nil),
+	C.ConvertorTypeSmartDNS:          (*SmartDNS)(nil),
+	C.ConvertorTypeUnbound:           (*Unbound)(nil),
+	C.ConvertorTypeAdGuardHome:       (*AdGuardHome)(nil),
+	C.ConvertorTypeV2RayGeoSite:      (*v2ray.GeoSiteList)(nil),
+	C.ConvertorTypeV2RayGeoIP:        (*v2ray.GeoIPList)(nil),
+	C.ConvertorTypeMMDB:              (*mmdb.Database)(nil),
+	C.ConvertorTypeSingBoxGeoSite:    (*singbox.GeoSite)(nil),
+	C.ConvertorTypeSingBoxGeoIP:      (*singbox.GeoIP)(nil),
 }
@@ -0,0 +1,128 @@
+package convertor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/srsc/adapter"
+)
+
+func writeDroppedComments(output *bytes.Buffer, dropped *adapter.ConvertReport) {
+	for _, item := range dropped.Items() {
+		output.WriteString(F.ToString("# dropped ", item.Count, " ", item.Reason, "\n"))
+	}
+}
+
+// dnsDomain is a domain matched by a DNS server rule.
+type dnsDomain struct {
+	Name string
+	// Exact matches only the domain, Subdomain matches only subdomains, otherwise both are matched.
+	Exact     bool
+	Subdomain bool
+}
+
+// collectDNSDomains returns domains of the destination address rules that can be matched by DNS servers.
+// domain items are matched exactly, and domain_suffix items starting with "." match only subdomains.
+// If the target cannot match exactly, domain items are matched with their subdomains,
+// which is the closest form, while domain_suffix items matching only subdomains are dropped if unsupported.
+// Dropped items are returned for comments and merged into the conversion report.
+func collectDNSDomains(ctx context.Context, contentRules []adapter.Rule, name string, report *adapter.ConvertReport, exact bool, subdomain bool) ([]dnsDomain, *adapter.ConvertReport, error) {
+	convertedRules, err := adapter.EmbedResourceRules(ctx, contentRules)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		suffixMap = make(map[string]bool)
+		domains   []dnsDomain
+		domainMap = make(map[dnsDomain]bool)
+		dropped   adapter.ConvertReport
+	)
+	for _, rule := range convertedRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			continue
+		}
+		for _, domainSuffix := range rule.DefaultOptions.DomainSuffix {
+			if !strings.HasPrefix(domainSuffix, ".") {
+				suffixMap[normalizeDNSDomain(domainSuffix)] = true
+			}
+		}
+	}
+	for _, rule := range convertedRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			dropped.DropRule(rule, name)
+			continue
+		}
+		for _, domain := range rule.DefaultOptions.Domain {
+			domains = appendDNSDomain(domains, domainMap, suffixMap, dnsDomain{Name: normalizeDNSDomain(domain), Exact: true})
+		}
+		for _, domainSuffix := range rule.DefaultOptions.DomainSuffix {
+			domain := dnsDomain{Name: normalizeDNSDomain(domainSuffix), Subdomain: strings.HasPrefix(domainSuffix, ".")}
+			if domain.Subdomain {
+				domains = appendDNSDomain(domains, domainMap, suffixMap, domain)
+			} else {
+				domains = appendDNSDomain(domains, domainMap, nil, domain)
+			}
+		}
+		dropped.DropDestinationItems(rule.DefaultOptions, name, "domain", "domain_suffix")
+	}
+	domains = mergeDNSDomains(domains, domainMap)
+	supported := domains[:0]
+	for _, domain := range domains {
+		if domain.Exact && !exact {
+			domain.Exact = false
+			if domainMap[domain] {
+				continue
+			}
+			domainMap[domain] = true
+			supported = append(supported, domain)
+		} else if domain.Subdomain && !subdomain {
+			dropped.Drop("domain_suffix items matching only subdomains unsupported by "+name, 1, "."+domain.Name)
+		} else {
+			supported = append(supported, domain)
+		}
+	}
+	report.Merge(&dropped)
+	return supported, &dropped, nil
+}
+
+func normalizeDNSDomain(domain string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(domain), "."), ".")
+}
+
+// appendDNSDomain appends the domain unless it is duplicated or covered by domain suffixes.
+func appendDNSDomain(domains []dnsDomain, domainMap map[dnsDomain]bool, suffixMap map[string]bool, domain dnsDomain) []dnsDomain {
+	if domain.Name == "" || domainMap[domain] {
+		return domains
+	}
+	for parent := domain.Name; suffixMap != nil; {
+		if suffixMap[parent] {
+			return domains
+		}
+		index := strings.IndexByte(parent, '.')
+		if index == -1 {
+			break
+		}
+		parent = parent[index+1:]
+	}
+	domainMap[domain] = true
+	return append(domains, domain)
+}
+
+// mergeDNSDomains replaces a domain matched both exactly and by subdomains with a domain suffix.
+func mergeDNSDomains(domains []dnsDomain, domainMap map[dnsDomain]bool) []dnsDomain {
+	merged := domains[:0]
+	for _, domain := range domains {
+		if (domain.Exact || domain.Subdomain) &&
+			domainMap[dnsDomain{Name: domain.Name, Exact: true}] && domainMap[dnsDomain{Name: domain.Name, Subdomain: true}] {
+			if domain.Subdomain {
+				continue
+			}
+			domain = dnsDomain{Name: domain.Name}
+		}
+		merged = append(merged, domain)
+	}
+	return merged
+}
@@ -0,0 +1,133 @@
+package convertor
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dnsTestRules() []adapter.Rule {
+	return []adapter.Rule{
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRule{
+				DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+					Domain:       []string{"exact.com", "www.example.org", "both.net"},
+					DomainSuffix: []string{"Example.org.", ".sub.io", ".both.net"},
+					IPCIDR:       []string{"1.1.1.1/32"},
+				},
+			},
+		},
+		{
+			Type: boxConstant.RuleTypeLogical,
+			LogicalOptions: adapter.LogicalRule{
+				Mode: boxConstant.LogicalTypeOr,
+			},
+		},
+	}
+}
+
+func TestDNSConvertors(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		name      string
+		convertor adapter.Convertor
+		options   option.TargetConvertOptions
+		output    string
+	}{
+		{
+			name:      "dnsmasq",
+			convertor: (*Dnsmasq)(nil),
+			options: option.TargetConvertOptions{DnsmasqOptions: option.DnsmasqTargetOptions{
+				Upstream: []string{"1.1.1.1"},
+				IPSet:    []string{"proxy4", "proxy6"},
+			}},
+			output: `# dropped 1 ip_cidr items unsupported by dnsmasq
+# dropped 1 logical rules unsupported by dnsmasq
+# dropped 1 domain_suffix items matching only subdomains unsupported by dnsmasq
+server=/exact.com/1.1.1.1
+ipset=/exact.com/proxy4,proxy6
+server=/both.net/1.1.1.1
+ipset=/both.net/proxy4,proxy6
+server=/example.org/1.1.1.1
+ipset=/example.org/proxy4,proxy6
+`,
+		},
+		{
+			name:      "SmartDNS",
+			convertor: (*SmartDNS)(nil),
+			options:   option.TargetConvertOptions{SmartDNSOptions: option.SmartDNSTargetOptions{Group: "proxy"}},
+			output: `# dropped 1 ip_cidr items unsupported by SmartDNS
+# dropped 1 logical rules unsupported by SmartDNS
+nameserver /-.exact.com/proxy
+nameserver /both.net/proxy
+nameserver /example.org/proxy
+nameserver /*.sub.io/proxy
+`,
+		},
+		{
+			name:      "Unbound",
+			convertor: (*Unbound)(nil),
+			output: `# dropped 1 ip_cidr items unsupported by Unbound
+# dropped 1 logical rules unsupported by Unbound
+# dropped 1 domain_suffix items matching only subdomains unsupported by Unbound
+local-zone: "exact.com." always_nxdomain
+local-zone: "both.net." always_nxdomain
+local-zone: "example.org." always_nxdomain
+`,
+		},
+		{
+			name:      "AdGuard Home",
+			convertor: (*AdGuardHome)(nil),
+			options:   option.TargetConvertOptions{AdGuardHomeOptions: option.AdGuardHomeTargetOptions{Upstream: []string{"1.1.1.1", "8.8.8.8"}}},
+			output: `# dropped 1 ip_cidr items unsupported by AdGuard Home
+# dropped 1 logical rules unsupported by AdGuard Home
+[/exact.com/]1.1.1.1 8.8.8.8
+[/*.exact.com/]#
+[/both.net/]1.1.1.1 8.8.8.8
+[/example.org/]1.1.1.1 8.8.8.8
+[/*.sub.io/]1.1.1.1 8.8.8.8
+`,
+		},
+	} {
+		var report adapter.ConvertReport
+		content, err := testCase.convertor.To(context.Background(), dnsTestRules(), adapter.ConvertOptions{
+			Options: option.ConvertOptions{TargetConvertOptions: testCase.options},
+			Report:  &report,
+		})
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, testCase.output, string(content), testCase.name)
+		require.NotZero(t, report.Dropped(), testCase.name)
+	}
+}
+
+func TestDNSConvertorsDomainOnly(t *testing.T) {
+	t.Parallel()
+	rules := []adapter.Rule{{
+		Type: boxConstant.RuleTypeDefault,
+		DefaultOptions: adapter.DefaultRule{
+			DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+				Domain: []string{"ads.example.com", "Tracker.Example.ORG."},
+			},
+		},
+	}}
+	var report adapter.ConvertReport
+	content, err := (*Dnsmasq)(nil).To(context.Background(), rules, adapter.ConvertOptions{
+		Options: option.ConvertOptions{TargetConvertOptions: option.TargetConvertOptions{DnsmasqOptions: option.DnsmasqTargetOptions{
+			Upstream: []string{"1.1.1.1"},
+		}}},
+		Report: &report,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "server=/ads.example.com/1.1.1.1\nserver=/tracker.example.org/1.1.1.1\n", string(content))
+	content, err = (*Unbound)(nil).To(context.Background(), rules, adapter.ConvertOptions{Report: &report})
+	require.NoError(t, err)
+	require.Equal(t, "local-zone: \"ads.example.com.\" always_nxdomain\nlocal-zone: \"tracker.example.org.\" always_nxdomain\n", string(content))
+	require.Zero(t, report.Dropped())
+}
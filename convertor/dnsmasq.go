@@ -0,0 +1,54 @@
+package convertor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*Dnsmasq)(nil)
+
+// Dnsmasq generates dnsmasq configurations, domain items are matched with their subdomains,
+// since dnsmasq domain rules always include subdomains.
+type Dnsmasq struct{}
+
+func (d *Dnsmasq) Type() string {
+	return C.ConvertorTypeDnsmasq
+}
+
+func (d *Dnsmasq) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (d *Dnsmasq) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return nil, E.New("dnsmasq configuration can only be used as target")
+}
+
+func (d *Dnsmasq) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	dnsmasqOptions := options.Options.TargetConvertOptions.DnsmasqOptions
+	if len(dnsmasqOptions.Upstream) == 0 && len(dnsmasqOptions.IPSet) == 0 && len(dnsmasqOptions.NFTSet) == 0 {
+		return nil, E.New("missing upstream, ipset or nftset in dnsmasq options")
+	}
+	domains, dropped, err := collectDNSDomains(ctx, contentRules, "dnsmasq", options.Report, false, false)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writeDroppedComments(&output, dropped)
+	for _, domain := range domains {
+		for _, upstream := range dnsmasqOptions.Upstream {
+			output.WriteString("server=/" + domain.Name + "/" + upstream + "\n")
+		}
+		if len(dnsmasqOptions.IPSet) > 0 {
+			output.WriteString("ipset=/" + domain.Name + "/" + strings.Join(dnsmasqOptions.IPSet, ",") + "\n")
+		}
+		if len(dnsmasqOptions.NFTSet) > 0 {
+			output.WriteString("nftset=/" + domain.Name + "/" + strings.Join(dnsmasqOptions.NFTSet, ",") + "\n")
+		}
+	}
+	return output.Bytes(), nil
+}
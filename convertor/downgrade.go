@@ -0,0 +1,117 @@
+package convertor
+
+import (
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/common/semver"
+	C "github.com/sagernet/srsc/constant"
+)
+
+// MinimumTargetVersion is the first sing-box version supporting rule-sets.
+var MinimumTargetVersion = semver.ParseVersion("1.8.0")
+
+type ruleSetCompatibility struct {
+	// version is the sing-box version introducing the rule-set version and items.
+	version        semver.Version
+	ruleSetVersion uint8
+	items          string
+	hasItems       func(rule option.DefaultHeadlessRule) bool
+}
+
+// ruleSetCompatibilities lists rule-set versions and headless rule items by the sing-box release introducing them, newest first.
+var ruleSetCompatibilities = []ruleSetCompatibility{
+	{
+		version:        semver.ParseVersion("1.11.0"),
+		ruleSetVersion: boxConstant.RuleSetVersion3,
+		items:          "network_type, network_is_expensive or network_is_constrained",
+		hasItems: func(rule option.DefaultHeadlessRule) bool {
+			return len(rule.NetworkType) > 0 || rule.NetworkIsExpensive || rule.NetworkIsConstrained
+		},
+	},
+	{
+		version:        semver.ParseVersion("1.10.0"),
+		ruleSetVersion: boxConstant.RuleSetVersion2,
+		items:          "process_path_regex or AdGuard rules",
+		hasItems: func(rule option.DefaultHeadlessRule) bool {
+			return len(rule.ProcessPathRegex) > 0 || len(rule.AdGuardDomain) > 0
+		},
+	},
+}
+
+// ParseTargetVersion parses the sing-box version to generate rule-sets for.
+func ParseTargetVersion(versionName string) (*semver.Version, error) {
+	if !semver.IsValid(versionName) {
+		return nil, E.New("invalid sing-box version: ", versionName)
+	}
+	version := semver.ParseVersion(versionName)
+	if version.LessThan(MinimumTargetVersion) {
+		return nil, E.New("rule-sets require sing-box ", MinimumTargetVersion.String(), " or later: ", versionName)
+	}
+	return &version, nil
+}
+
+// TargetVersion returns the sing-box version to generate rule-sets for, or nil for the current version.
+// The version of the sing-box client takes precedence, target_version applies to clients of unknown versions.
+func TargetVersion(options adapter.ConvertOptions) (*semver.Version, error) {
+	if options.Metadata.Platform == C.PlatformSingBox && options.Metadata.Version != nil {
+		return options.Metadata.Version, nil
+	}
+	targetVersion := options.Options.RuleSetOptions.TargetVersion
+	if targetVersion == "" {
+		return nil, nil
+	}
+	version, err := ParseTargetVersion(targetVersion)
+	if err != nil {
+		return nil, E.Cause(err, "parse target_version")
+	}
+	return version, nil
+}
+
+// CompatibilityName returns the name of the oldest compatibility table entry unsupported by the sing-box version,
+// or empty if the version supports all. Versions of the same name generate the same rule-sets.
+func CompatibilityName(version *semver.Version) string {
+	var name string
+	for _, compatibility := range ruleSetCompatibilities {
+		if version.LessThan(compatibility.version) {
+			name = "pre-" + compatibility.version.String()
+		}
+	}
+	return name
+}
+
+// Downgrade converts the rule-set to the rule-set version supported by the sing-box version,
+// rules with items unsupported by the version are dropped.
+func Downgrade(source *option.PlainRuleSetCompat, version *semver.Version, report *adapter.ConvertReport) {
+	for _, compatibility := range ruleSetCompatibilities {
+		if !version.LessThan(compatibility.version) {
+			break
+		}
+		if source.Version >= compatibility.ruleSetVersion {
+			source.Version = compatibility.ruleSetVersion - 1
+		}
+		rulesBefore := len(source.Options.Rules)
+		source.Options.Rules = common.Filter(source.Options.Rules, func(it option.HeadlessRule) bool {
+			return !hasRule([]option.HeadlessRule{it}, compatibility.hasItems)
+		})
+		report.Drop("rules with "+compatibility.items+" unsupported before sing-box "+compatibility.version.String(), rulesBefore-len(source.Options.Rules))
+	}
+}
+
+func hasRule(rules []option.HeadlessRule, cond func(rule option.DefaultHeadlessRule) bool) bool {
+	for _, rule := range rules {
+		switch rule.Type {
+		case boxConstant.RuleTypeDefault:
+			if cond(rule.DefaultOptions) {
+				return true
+			}
+		case boxConstant.RuleTypeLogical:
+			if hasRule(rule.LogicalOptions.Rules, cond) {
+				return true
+			}
+		}
+	}
+	return false
+}
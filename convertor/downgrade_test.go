@@ -0,0 +1,56 @@
+package convertor
+
+import (
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/common/semver"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDowngrade(t *testing.T) {
+	t.Parallel()
+	newRuleSet := func() *option.PlainRuleSetCompat {
+		return &option.PlainRuleSetCompat{
+			Version: boxConstant.RuleSetVersionCurrent,
+			Options: option.PlainRuleSet{
+				Rules: []option.HeadlessRule{
+					{Type: boxConstant.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{Domain: []string{"example.com"}}},
+					{Type: boxConstant.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{ProcessPathRegex: []string{"^/usr/bin/"}}},
+					{Type: boxConstant.RuleTypeLogical, LogicalOptions: option.LogicalHeadlessRule{
+						Mode: boxConstant.LogicalTypeAnd,
+						Rules: []option.HeadlessRule{
+							{Type: boxConstant.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{Domain: []string{"example.org"}}},
+							{Type: boxConstant.RuleTypeDefault, DefaultOptions: option.DefaultHeadlessRule{NetworkIsExpensive: true}},
+						},
+					}},
+				},
+			},
+		}
+	}
+	for _, testCase := range []struct {
+		version        string
+		ruleSetVersion uint8
+		rules          int
+		name           string
+	}{
+		{"1.12.0", boxConstant.RuleSetVersion3, 3, ""},
+		{"1.11.0-beta.1", boxConstant.RuleSetVersion2, 2, "pre-1.11.0"},
+		{"1.10.5", boxConstant.RuleSetVersion2, 2, "pre-1.11.0"},
+		{"1.9.0", boxConstant.RuleSetVersion1, 1, "pre-1.10.0"},
+	} {
+		version := semver.ParseVersion(testCase.version)
+		ruleSet := newRuleSet()
+		var report adapter.ConvertReport
+		Downgrade(ruleSet, &version, &report)
+		require.Equal(t, testCase.ruleSetVersion, ruleSet.Version, testCase.version)
+		require.Len(t, ruleSet.Options.Rules, testCase.rules, testCase.version)
+		require.Equal(t, 3-testCase.rules, report.Dropped(), testCase.version)
+		require.Equal(t, testCase.name, CompatibilityName(&version), testCase.version)
+	}
+	_, err := ParseTargetVersion("1.7.0")
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package convertor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/netip"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*Hosts)(nil)
+
+type Hosts struct{}
+
+func (h *Hosts) Type() string {
+	return C.ConvertorTypeHosts
+}
+
+func (h *Hosts) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (h *Hosts) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	var rule adapter.DefaultRule
+	domainMap := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		ruleLine := strings.TrimSpace(common.SubstringBefore(scanner.Text(), "#"))
+		if ruleLine == "" {
+			continue
+		}
+		hostFields := strings.Fields(ruleLine)
+		if len(hostFields) < 2 {
+			options.Report.Drop("invalid hosts lines", 1, ruleLine)
+			continue
+		}
+		_, err := netip.ParseAddr(hostFields[0])
+		if err != nil {
+			options.Report.Drop("invalid hosts lines", 1, ruleLine)
+			continue
+		}
+		for _, hostname := range hostFields[1:] {
+			hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+			if isLocalHostname(hostname) || M.ParseAddr(hostname).IsValid() || !M.IsDomainName(hostname) || domainMap[hostname] {
+				continue
+			}
+			domainMap[hostname] = true
+			rule.Domain = append(rule.Domain, hostname)
+		}
+	}
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (h *Hosts) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	var output bytes.Buffer
+	for _, rule := range contentRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			options.Report.DropRule(rule, "hosts")
+			continue
+		}
+		options.Report.DropDestinationItems(rule.DefaultOptions, "hosts", "domain")
+		for _, domain := range rule.DefaultOptions.Domain {
+			output.WriteString("0.0.0.0 " + domain + "\n")
+		}
+	}
+	return output.Bytes(), nil
+}
+
+func isLocalHostname(hostname string) bool {
+	switch hostname {
+	case "localhost", "localhost.localdomain", "local", "broadcasthost",
+		"ip6-localhost", "ip6-loopback", "ip6-localnet", "ip6-mcastprefix",
+		"ip6-allnodes", "ip6-allrouters", "ip6-allhosts":
+		return true
+	default:
+		return false
+	}
+}
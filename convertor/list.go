@@ -0,0 +1,178 @@
+package convertor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/netip"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+
+	"go4.org/netipx"
+)
+
+var (
+	_ adapter.Convertor = (*DomainList)(nil)
+	_ adapter.Convertor = (*IPList)(nil)
+)
+
+type DomainList struct{}
+
+func (l *DomainList) Type() string {
+	return C.ConvertorTypeDomainList
+}
+
+func (l *DomainList) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (l *DomainList) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	var rule adapter.DefaultRule
+	domainMap := make(map[string]bool)
+	domainSuffixMap := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		ruleLine := readListLine(scanner.Text())
+		if ruleLine == "" {
+			continue
+		}
+		ruleLine = strings.TrimSuffix(strings.ToLower(ruleLine), ".")
+		// +. and *. include the domain itself, while a leading dot matches subdomains only.
+		var (
+			domainSuffix  bool
+			subdomainOnly bool
+		)
+		for _, prefix := range []string{"+.", "*.", "."} {
+			if strings.HasPrefix(ruleLine, prefix) {
+				domainSuffix = true
+				subdomainOnly = prefix == "."
+				ruleLine = strings.TrimPrefix(ruleLine, prefix)
+				break
+			}
+		}
+		if !M.IsDomainName(ruleLine) {
+			options.Report.Drop("invalid domains", 1, ruleLine)
+			continue
+		}
+		if !domainSuffix {
+			if !domainMap[ruleLine] {
+				domainMap[ruleLine] = true
+				rule.Domain = append(rule.Domain, ruleLine)
+			}
+			continue
+		}
+		if subdomainOnly {
+			ruleLine = "." + ruleLine
+		}
+		if !domainSuffixMap[ruleLine] {
+			domainSuffixMap[ruleLine] = true
+			rule.DomainSuffix = append(rule.DomainSuffix, ruleLine)
+		}
+	}
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (l *DomainList) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	var output bytes.Buffer
+	for _, rule := range contentRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			options.Report.DropRule(rule, "domain list")
+			continue
+		}
+		options.Report.DropDestinationItems(rule.DefaultOptions, "domain list", "domain", "domain_suffix")
+		for _, domain := range rule.DefaultOptions.Domain {
+			output.WriteString(domain + "\n")
+		}
+		for _, domainSuffix := range rule.DefaultOptions.DomainSuffix {
+			if strings.HasPrefix(domainSuffix, ".") {
+				output.WriteString(domainSuffix + "\n")
+			} else {
+				output.WriteString("+." + domainSuffix + "\n")
+			}
+		}
+	}
+	return output.Bytes(), nil
+}
+
+type IPList struct{}
+
+func (l *IPList) Type() string {
+	return C.ConvertorTypeIPList
+}
+
+func (l *IPList) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (l *IPList) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	var rule adapter.DefaultRule
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		ruleLine := readListLine(scanner.Text())
+		if ruleLine == "" {
+			continue
+		}
+		prefixes, err := parseIPListLine(ruleLine)
+		if err != nil {
+			options.Report.Drop("invalid IP CIDRs", 1, ruleLine)
+			continue
+		}
+		rule.IPCIDR = append(rule.IPCIDR, common.Map(prefixes, netip.Prefix.String)...)
+	}
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (l *IPList) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	var output bytes.Buffer
+	for _, rule := range contentRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			options.Report.DropRule(rule, "IP list")
+			continue
+		}
+		options.Report.DropDestinationItems(rule.DefaultOptions, "IP list", "ip_cidr")
+		for _, ipCidr := range rule.DefaultOptions.IPCIDR {
+			prefixes, err := parseIPListLine(ipCidr)
+			if err != nil {
+				options.Report.Drop("invalid IP CIDRs", 1, ipCidr)
+				continue
+			}
+			for _, prefix := range prefixes {
+				output.WriteString(prefix.String() + "\n")
+			}
+		}
+	}
+	return output.Bytes(), nil
+}
+
+func readListLine(line string) string {
+	line = common.SubstringBefore(line, "#")
+	line = common.SubstringBefore(line, ";")
+	return strings.TrimSpace(line)
+}
+
+func parseIPListLine(ruleLine string) ([]netip.Prefix, error) {
+	if strings.Contains(ruleLine, "/") {
+		prefix, err := netip.ParsePrefix(ruleLine)
+		if err != nil {
+			return nil, err
+		}
+		return []netip.Prefix{prefix.Masked()}, nil
+	} else if strings.Contains(ruleLine, "-") {
+		ipRange, err := netipx.ParseIPRange(ruleLine)
+		if err != nil {
+			return nil, err
+		}
+		return ipRange.Prefixes(), nil
+	} else {
+		address, err := netip.ParseAddr(ruleLine)
+		if err != nil {
+			return nil, err
+		}
+		return []netip.Prefix{netip.PrefixFrom(address, address.BitLen())}, nil
+	}
+}
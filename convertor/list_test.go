@@ -0,0 +1,85 @@
+package convertor
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHosts(t *testing.T) {
+	t.Parallel()
+	rules, err := (*Hosts)(nil).From(context.Background(), []byte(`
+# comment
+127.0.0.1 localhost localhost.localdomain
+::1 localhost ip6-localhost ip6-loopback #[IPv6]
+fe80::1%lo0 localhost
+255.255.255.255 broadcasthost
+0.0.0.0 0.0.0.0
+0.0.0.0 ads.example.com # inline comment
+0.0.0.0 tracker.example.com Tracker.Example.ORG.
+:: ipv6.example.com
+invalid line
+`), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"ads.example.com", "tracker.example.com", "tracker.example.org", "ipv6.example.com"}, rules[0].DefaultOptions.Domain)
+	content, err := (*Hosts)(nil).To(context.Background(), rules, adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n0.0.0.0 tracker.example.org\n0.0.0.0 ipv6.example.com\n", string(content))
+	var report adapter.ConvertReport
+	content, err = (*Hosts)(nil).To(context.Background(), []adapter.Rule{{
+		Type: boxConstant.RuleTypeDefault,
+		DefaultOptions: adapter.DefaultRule{
+			DefaultHeadlessRule: boxOption.DefaultHeadlessRule{
+				Domain:       []string{"example.com"},
+				DomainSuffix: []string{"example.org", ".example.net"},
+			},
+		},
+	}}, adapter.ConvertOptions{Report: &report})
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0 example.com\n", string(content))
+	require.Equal(t, []adapter.ConvertReportItem{{
+		Reason:  "domain_suffix items unsupported by hosts",
+		Count:   2,
+		Samples: []string{"example.org", ".example.net"},
+	}}, report.Items())
+}
+
+func TestDomainList(t *testing.T) {
+	t.Parallel()
+	rules, err := (*DomainList)(nil).From(context.Background(), []byte(`
+example.com
+Example.COM.
+.example.org # suffix
+.Example.org
++.example.net
+*.example.edu
+invalid domain
+`), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{".example.org", "example.net", "example.edu"}, rules[0].DefaultOptions.DomainSuffix)
+	content, err := (*DomainList)(nil).To(context.Background(), rules, adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "example.com\n.example.org\n+.example.net\n+.example.edu\n", string(content))
+}
+
+func TestIPList(t *testing.T) {
+	t.Parallel()
+	rules, err := (*IPList)(nil).From(context.Background(), []byte(`
+1.1.1.1
+10.0.0.1/8 # inline comment
+2001:db8::/32
+192.168.0.0-192.168.1.255
+`), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"1.1.1.1/32", "10.0.0.0/8", "2001:db8::/32", "192.168.0.0/23"}, rules[0].DefaultOptions.IPCIDR)
+}
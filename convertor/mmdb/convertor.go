@@ -0,0 +1,183 @@
+package mmdb
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/source"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go4.org/netipx"
+)
+
+var _ adapter.Convertor = (*Database)(nil)
+
+type Database struct{}
+
+func (d *Database) Type() string {
+	return C.ConvertorTypeMMDB
+}
+
+func (d *Database) ContentType(_ adapter.ConvertOptions) string {
+	return "application/octet-stream"
+}
+
+func (d *Database) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return Read(content, options.Options.SourceConvertOptions.MMDBOptions.Code, options.Params)
+}
+
+func (d *Database) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	return nil, E.New("MMDB can only be used as source")
+}
+
+const (
+	databaseKindCountry = iota
+	databaseKindASN
+	databaseKindSingGeoIP
+)
+
+var databaseKinds = map[string]int{
+	"GeoLite2-Country":                    databaseKindCountry,
+	"GeoLite2-City":                       databaseKindCountry,
+	"GeoIP2-Country":                      databaseKindCountry,
+	"GeoIP2-City":                         databaseKindCountry,
+	"GeoIP2-Enterprise":                   databaseKindCountry,
+	"DBIP-Country-Lite":                   databaseKindCountry,
+	"DBIP-City-Lite":                      databaseKindCountry,
+	"GeoLite2-ASN":                        databaseKindASN,
+	"GeoIP2-ASN":                          databaseKindASN,
+	"DBIP-ASN-Lite":                       databaseKindASN,
+	"DBIP-ASN-Lite (compat=GeoLite2-ASN)": databaseKindASN,
+	"sing-geoip":                          databaseKindSingGeoIP,
+}
+
+// maxCachedReaders is the number of databases kept open, usually a country and an ASN database.
+const maxCachedReaders = 4
+
+type cachedReader struct {
+	digest string
+	reader *maxminddb.Reader
+}
+
+var (
+	readerAccess sync.Mutex
+	readerCache  []cachedReader
+)
+
+// openReader opens the database once per content, since each uncached code reads the same content.
+func openReader(content []byte) (*maxminddb.Reader, error) {
+	digest := source.Digest(content)
+	readerAccess.Lock()
+	defer readerAccess.Unlock()
+	for index, cached := range readerCache {
+		if cached.digest == digest {
+			copy(readerCache[1:index+1], readerCache[:index])
+			readerCache[0] = cached
+			return cached.reader, nil
+		}
+	}
+	reader, err := maxminddb.FromBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(readerCache) == maxCachedReaders {
+		readerCache = readerCache[:maxCachedReaders-1]
+	}
+	readerCache = append([]cachedReader{{digest, reader}}, readerCache...)
+	return reader, nil
+}
+
+// Read enumerates all networks of the country code or ASN in the database,
+// the `code` or `asn` parameter is used if code is empty.
+func Read(content []byte, code string, params map[string]string) ([]adapter.Rule, error) {
+	reader, err := openReader(content)
+	if err != nil {
+		return nil, E.Cause(err, "open MMDB")
+	}
+	databaseType := reader.Metadata.DatabaseType
+	databaseKind, loaded := databaseKinds[databaseType]
+	if !loaded {
+		return nil, E.New("unsupported MMDB database type: ", databaseType)
+	}
+	var match func(networks *maxminddb.Networks) (*net.IPNet, bool, error)
+	switch databaseKind {
+	case databaseKindASN:
+		if code == "" {
+			code = params["asn"]
+		}
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(code), "AS"), 10, 32)
+		if err != nil {
+			return nil, E.Cause(err, "parse ASN: ", code)
+		}
+		match = func(networks *maxminddb.Networks) (*net.IPNet, bool, error) {
+			var record struct {
+				ASN uint64 `maxminddb:"autonomous_system_number"`
+			}
+			network, err := networks.Network(&record)
+			return network, record.ASN == asn, err
+		}
+	case databaseKindSingGeoIP:
+		if code == "" {
+			code = params["code"]
+		}
+		match = func(networks *maxminddb.Networks) (*net.IPNet, bool, error) {
+			var record string
+			network, err := networks.Network(&record)
+			return network, strings.EqualFold(record, code), err
+		}
+	default:
+		if code == "" {
+			code = params["code"]
+		}
+		match = func(networks *maxminddb.Networks) (*net.IPNet, bool, error) {
+			var record struct {
+				Country struct {
+					ISOCode string `maxminddb:"iso_code"`
+				} `maxminddb:"country"`
+			}
+			network, err := networks.Network(&record)
+			return network, strings.EqualFold(record.Country.ISOCode, code), err
+		}
+	}
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	var builder netipx.IPSetBuilder
+	networks := reader.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		network, matched, err := match(networks)
+		if err != nil {
+			return nil, E.Cause(err, "decode MMDB record")
+		}
+		if !matched {
+			continue
+		}
+		prefix, ok := netipx.FromStdIPNet(network)
+		if !ok {
+			return nil, E.New("invalid MMDB network: ", network)
+		}
+		builder.AddPrefix(prefix)
+	}
+	if err = networks.Err(); err != nil {
+		return nil, E.Cause(err, "read MMDB")
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	var rule adapter.DefaultRule
+	for _, prefix := range ipSet.Prefixes() {
+		rule.IPCIDR = append(rule.IPCIDR, prefix.String())
+	}
+	if len(rule.IPCIDR) == 0 {
+		return nil, E.New("code not found: ", code)
+	}
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
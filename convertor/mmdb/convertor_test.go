@@ -0,0 +1,87 @@
+package mmdb
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/require"
+)
+
+func buildDatabase(t *testing.T, databaseType string, records map[string]mmdbtype.DataType) []byte {
+	writer, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: databaseType})
+	require.NoError(t, err)
+	for cidr, record := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, writer.Insert(network, record))
+	}
+	var buffer bytes.Buffer
+	_, err = writer.WriteTo(&buffer)
+	require.NoError(t, err)
+	return buffer.Bytes()
+}
+
+func country(code string) mmdbtype.DataType {
+	return mmdbtype.Map{"country": mmdbtype.Map{"iso_code": mmdbtype.String(code)}}
+}
+
+func asn(number uint32) mmdbtype.DataType {
+	return mmdbtype.Map{"autonomous_system_number": mmdbtype.Uint32(number)}
+}
+
+func TestReadCountry(t *testing.T) {
+	t.Parallel()
+	content := buildDatabase(t, "GeoLite2-Country", map[string]mmdbtype.DataType{
+		"1.0.1.0/24":     country("CN"),
+		"1.0.2.0/24":     country("CN"),
+		"1.1.1.0/24":     country("AU"),
+		"2400:3200::/32": country("CN"),
+	})
+	rules, err := Read(content, "cn", nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"1.0.1.0/24", "1.0.2.0/24", "2400:3200::/32"}, []string(rules[0].DefaultOptions.IPCIDR))
+	rules, err = Read(content, "", map[string]string{"code": "au"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.1.1.0/24"}, []string(rules[0].DefaultOptions.IPCIDR))
+	_, err = Read(content, "us", nil)
+	require.ErrorContains(t, err, "code not found")
+	_, err = Read(content, "", nil)
+	require.ErrorContains(t, err, "missing code")
+	reader, err := openReader(content)
+	require.NoError(t, err)
+	cachedReader, err := openReader(bytes.Clone(content))
+	require.NoError(t, err)
+	require.Same(t, reader, cachedReader)
+}
+
+func TestReadASN(t *testing.T) {
+	t.Parallel()
+	content := buildDatabase(t, "GeoLite2-ASN", map[string]mmdbtype.DataType{
+		"1.1.1.0/24":     asn(13335),
+		"104.16.0.0/13":  asn(13335),
+		"8.8.8.0/24":     asn(15169),
+		"2606:4700::/32": asn(13335),
+		"2001:4860::/32": asn(15169),
+	})
+	rules, err := Read(content, "AS13335", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.1.1.0/24", "104.16.0.0/13", "2606:4700::/32"}, []string(rules[0].DefaultOptions.IPCIDR))
+	rules, err = Read(content, "", map[string]string{"asn": "15169"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"8.8.8.0/24", "2001:4860::/32"}, []string(rules[0].DefaultOptions.IPCIDR))
+	_, err = Read(content, "ASN", nil)
+	require.ErrorContains(t, err, "parse ASN")
+}
+
+func TestReadUnsupported(t *testing.T) {
+	t.Parallel()
+	content := buildDatabase(t, "GeoLite2-ASN-Extended", map[string]mmdbtype.DataType{
+		"1.1.1.0/24": asn(13335),
+	})
+	_, err := Read(content, "AS13335", nil)
+	require.ErrorContains(t, err, "unsupported MMDB database type")
+}
@@ -0,0 +1,157 @@
+package convertor
+
+import (
+	"net/netip"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/convertor/internal/meta_cidr"
+)
+
+// Optimize merges destination address rules and shrinks items of each rule
+// without changing what the rules match.
+func Optimize(rules []adapter.Rule) ([]adapter.Rule, error) {
+	rules = adapter.MergeRules(rules)
+	for i := range rules {
+		err := optimizeRule(&rules[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func optimizeRule(rule *adapter.Rule) error {
+	if rule.Type == boxConstant.RuleTypeLogical {
+		for i := range rule.LogicalOptions.Rules {
+			err := optimizeRule(&rule.LogicalOptions.Rules[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return optimizeDefaultRule(&rule.DefaultOptions)
+}
+
+func optimizeDefaultRule(rule *adapter.DefaultRule) error {
+	rule.Domain, rule.DomainSuffix = optimizeDomains(normalizeDomains(rule.Domain), normalizeDomains(rule.DomainSuffix))
+	rule.DomainKeyword = uniq(normalizeDomains(rule.DomainKeyword))
+	rule.DomainRegex = uniq(rule.DomainRegex)
+	ipCIDR, err := optimizeIPCIDR(rule.IPCIDR)
+	if err != nil {
+		return E.Cause(err, "optimize ip_cidr")
+	}
+	rule.IPCIDR = ipCIDR
+	sourceIPCIDR, err := optimizeIPCIDR(rule.SourceIPCIDR)
+	if err != nil {
+		return E.Cause(err, "optimize source_ip_cidr")
+	}
+	rule.SourceIPCIDR = sourceIPCIDR
+	rule.ProcessName = uniq(rule.ProcessName)
+	rule.ProcessPath = uniq(rule.ProcessPath)
+	rule.PackageName = uniq(rule.PackageName)
+	rule.GEOIP = uniq(rule.GEOIP)
+	rule.IPASN = uniq(rule.IPASN)
+	rule.GEOSite = uniq(rule.GEOSite)
+	rule.RuleSet = uniq(rule.RuleSet)
+	rule.DomainSet = uniq(rule.DomainSet)
+	return nil
+}
+
+func uniq(items []string) []string {
+	if len(items) == 0 {
+		return items
+	}
+	return common.Uniq(items)
+}
+
+func normalizeDomains(domains []string) []string {
+	if len(domains) == 0 {
+		return domains
+	}
+	return common.Map(domains, func(it string) string {
+		return strings.TrimSuffix(strings.ToLower(it), ".")
+	})
+}
+
+// optimizeDomains removes duplicates and items covered by a broader domain_suffix item.
+// A domain_suffix item without leading dot matches the domain itself and its subdomains,
+// one with leading dot matches subdomains only.
+func optimizeDomains(domains []string, domainSuffixes []string) ([]string, []string) {
+	suffixSet := make(map[string]bool)
+	subdomainSuffixSet := make(map[string]bool)
+	for _, suffix := range domainSuffixes {
+		if strings.HasPrefix(suffix, ".") {
+			subdomainSuffixSet[suffix[1:]] = true
+		} else {
+			suffixSet[suffix] = true
+		}
+	}
+	coveredByParent := func(domain string) bool {
+		for {
+			index := strings.IndexByte(domain, '.')
+			if index == -1 {
+				return false
+			}
+			domain = domain[index+1:]
+			if suffixSet[domain] || subdomainSuffixSet[domain] {
+				return true
+			}
+		}
+	}
+	var newDomains []string
+	for _, domain := range common.Uniq(domains) {
+		if suffixSet[domain] || coveredByParent(domain) {
+			continue
+		}
+		newDomains = append(newDomains, domain)
+	}
+	var newDomainSuffixes []string
+	for _, suffix := range common.Uniq(domainSuffixes) {
+		if strings.HasPrefix(suffix, ".") {
+			if suffixSet[suffix[1:]] || coveredByParent(suffix[1:]) {
+				continue
+			}
+		} else if coveredByParent(suffix) {
+			continue
+		}
+		newDomainSuffixes = append(newDomainSuffixes, suffix)
+	}
+	return newDomains, newDomainSuffixes
+}
+
+// optimizeIPCIDR merges items into a minimal prefix set.
+func optimizeIPCIDR(ipCIDR []string) ([]string, error) {
+	if len(ipCIDR) == 0 {
+		return ipCIDR, nil
+	}
+	ipCidrSet := cidr.NewIpCidrSet()
+	for _, item := range ipCIDR {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(item)
+			if addrErr != nil {
+				return nil, err
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		err = ipCidrSet.AddIpCidr(prefix.Masked())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := ipCidrSet.Merge()
+	if err != nil {
+		return nil, err
+	}
+	var newIPCIDR []string
+	ipCidrSet.Foreach(func(prefix netip.Prefix) bool {
+		newIPCIDR = append(newIPCIDR, prefix.String())
+		return true
+	})
+	return newIPCIDR, nil
+}
@@ -0,0 +1,45 @@
+package convertor
+
+import (
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+	rules, err := Optimize([]adapter.Rule{
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+				Domain:       []string{"example.com", "a.example.com", "Example.ORG.", "example.org", "example.net"},
+				DomainSuffix: []string{"example.com", ".example.com", "b.example.com", ".example.net", ".c.example.net"},
+				IPCIDR:       []string{"10.0.0.0/25", "10.0.0.128/25", "10.0.0.1", "2001:db8::/33", "2001:db8:8000::/33"},
+			}),
+		},
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+				Domain: []string{"example.org"},
+			}),
+		},
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+				Domain: []string{"d.example.com"},
+				Port:   []uint16{443},
+			}),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, badoption.Listable[string]{"example.org", "example.net"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.com", ".example.net"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/24", "2001:db8::/32"}, rules[0].DefaultOptions.IPCIDR)
+	require.Equal(t, badoption.Listable[string]{"d.example.com"}, rules[1].DefaultOptions.Domain)
+}
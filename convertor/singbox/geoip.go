@@ -0,0 +1,31 @@
+package singbox
+
+import (
+	"context"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor/mmdb"
+)
+
+var _ adapter.Convertor = (*GeoIP)(nil)
+
+// GeoIP reads the legacy sing-box geoip.db, which is a MMDB database of the `sing-geoip` type.
+type GeoIP struct{}
+
+func (g *GeoIP) Type() string {
+	return C.ConvertorTypeSingBoxGeoIP
+}
+
+func (g *GeoIP) ContentType(_ adapter.ConvertOptions) string {
+	return "application/octet-stream"
+}
+
+func (g *GeoIP) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return mmdb.Read(content, options.Options.SourceConvertOptions.SingBoxOptions.Code, options.Params)
+}
+
+func (g *GeoIP) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	return nil, E.New("sing-box geoip database can only be used as source")
+}
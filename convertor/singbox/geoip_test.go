@@ -0,0 +1,64 @@
+package singbox
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/geoip"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoIP(t *testing.T) {
+	t.Parallel()
+	writer, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType: "sing-geoip",
+		Languages:    []string{"cn", "us"},
+		IPVersion:    6,
+		RecordSize:   24,
+	})
+	require.NoError(t, err)
+	for cidr, code := range map[string]string{
+		"1.0.1.0/24":     "cn",
+		"1.0.2.0/23":     "cn",
+		"8.8.8.0/24":     "us",
+		"2400:3200::/32": "cn",
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, writer.Insert(network, mmdbtype.String(code)))
+	}
+	path := filepath.Join(t.TempDir(), "geoip.db")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = writer.WriteTo(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	reader, codes, err := geoip.Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	require.Equal(t, []string{"cn", "us"}, codes)
+	require.Equal(t, "cn", reader.Lookup(netip.MustParseAddr("1.0.3.1")))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	rules, err := (*GeoIP)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "CN"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, []string{"1.0.1.0/24", "1.0.2.0/23", "2400:3200::/32"}, []string(rules[0].DefaultOptions.IPCIDR))
+	for _, prefix := range rules[0].DefaultOptions.IPCIDR {
+		require.Equal(t, "cn", reader.Lookup(netip.MustParsePrefix(prefix).Addr()))
+	}
+	_, err = (*GeoIP)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "jp"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package singbox
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/geosite"
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*GeoSite)(nil)
+
+type GeoSite struct{}
+
+func (g *GeoSite) Type() string {
+	return C.ConvertorTypeSingBoxGeoSite
+}
+
+func (g *GeoSite) ContentType(_ adapter.ConvertOptions) string {
+	return "application/octet-stream"
+}
+
+func (g *GeoSite) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	code := sourceCode(options)
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	reader, _, err := geosite.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, E.Cause(err, "open geosite database")
+	}
+	items, err := reader.Read(strings.ToLower(code))
+	if err != nil {
+		return nil, err
+	}
+	compiledRule := geosite.Compile(items)
+	var rule adapter.DefaultRule
+	rule.Domain = compiledRule.Domain
+	rule.DomainSuffix = compiledRule.DomainSuffix
+	rule.DomainKeyword = compiledRule.DomainKeyword
+	rule.DomainRegex = compiledRule.DomainRegex
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (g *GeoSite) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	return nil, E.New("sing-box geosite database can only be used as source")
+}
+
+func sourceCode(options adapter.ConvertOptions) string {
+	if options.Options.SourceConvertOptions.SingBoxOptions.Code != "" {
+		return options.Options.SourceConvertOptions.SingBoxOptions.Code
+	}
+	return options.Params["code"]
+}
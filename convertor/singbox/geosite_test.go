@@ -0,0 +1,41 @@
+package singbox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/geosite"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoSite(t *testing.T) {
+	t.Parallel()
+	var buffer bytes.Buffer
+	err := geosite.Write(&buffer, map[string][]geosite.Item{
+		"example": {
+			{Type: geosite.RuleTypeDomain, Value: "example.com"},
+			{Type: geosite.RuleTypeDomainSuffix, Value: ".example.com"},
+			{Type: geosite.RuleTypeDomainKeyword, Value: "example"},
+		},
+		"other": {
+			{Type: geosite.RuleTypeDomain, Value: "example.org"},
+		},
+	})
+	require.NoError(t, err)
+	rules, err := (*GeoSite)(nil).From(context.Background(), buffer.Bytes(), adapter.ConvertOptions{
+		Params: map[string]string{"code": "EXAMPLE"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{".example.com"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"example"}, rules[0].DefaultOptions.DomainKeyword)
+	_, err = (*GeoSite)(nil).From(context.Background(), buffer.Bytes(), adapter.ConvertOptions{
+		Params: map[string]string{"code": "missing"},
+	})
+	require.Error(t, err)
+}
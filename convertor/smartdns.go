@@ -0,0 +1,50 @@
+package convertor
+
+import (
+	"bytes"
+	"context"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*SmartDNS)(nil)
+
+type SmartDNS struct{}
+
+func (s *SmartDNS) Type() string {
+	return C.ConvertorTypeSmartDNS
+}
+
+func (s *SmartDNS) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (s *SmartDNS) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return nil, E.New("SmartDNS configuration can only be used as target")
+}
+
+func (s *SmartDNS) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	group := options.Options.TargetConvertOptions.SmartDNSOptions.Group
+	if group == "" {
+		return nil, E.New("missing group in SmartDNS options")
+	}
+	domains, dropped, err := collectDNSDomains(ctx, contentRules, "SmartDNS", options.Report, true, true)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writeDroppedComments(&output, dropped)
+	for _, domain := range domains {
+		// SmartDNS matches subdomains only with the "*." prefix, and the domain only with the "-." prefix.
+		name := domain.Name
+		if domain.Exact {
+			name = "-." + name
+		} else if domain.Subdomain {
+			name = "*." + name
+		}
+		output.WriteString("nameserver /" + name + "/" + group + "\n")
+	}
+	return output.Bytes(), nil
+}
@@ -11,7 +11,6 @@ import (
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/srsc/adapter"
-	"github.com/sagernet/srsc/common/semver"
 	C "github.com/sagernet/srsc/constant"
 )
 
@@ -46,13 +45,15 @@ func (s *RuleSetSource) To(ctx context.Context, contentRules []adapter.Rule, opt
 	ruleSet := &option.PlainRuleSetCompat{
 		Version: boxConstant.RuleSetVersionCurrent,
 		Options: option.PlainRuleSet{
-			Rules: common.Map(common.Filter(convertedRules, func(it adapter.Rule) bool {
-				return it.Headlessable()
-			}), adapter.Rule.ToHeadless),
+			Rules: toHeadlessRules(convertedRules, options.Report),
 		},
 	}
-	if options.Metadata.Platform == C.PlatformSingBox && options.Metadata.Version != nil {
-		Downgrade(ruleSet, options.Metadata.Version)
+	version, err := TargetVersion(options)
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		Downgrade(ruleSet, version, options.Report)
 	}
 	buffer := new(bytes.Buffer)
 	encoder := json.NewEncoder(buffer)
@@ -64,34 +65,47 @@ func (s *RuleSetSource) To(ctx context.Context, contentRules []adapter.Rule, opt
 	return buffer.Bytes(), nil
 }
 
-func Downgrade(source *option.PlainRuleSetCompat, version *semver.Version) {
-	if version.LessThan(semver.ParseVersion("1.11.0")) {
-		source.Version = boxConstant.RuleSetVersion2
-		source.Options.Rules = common.Filter(source.Options.Rules, filter1100Rule)
-	}
-	if version.LessThan(semver.ParseVersion("1.10.0")) {
-		source.Version = boxConstant.RuleSetVersion1
+// toHeadlessRules converts rules to rule-set rules, reporting rules with items unsupported by sing-box rule-sets.
+func toHeadlessRules(rules []adapter.Rule, report *adapter.ConvertReport) []option.HeadlessRule {
+	headlessRules := make([]option.HeadlessRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Headlessable() {
+			if rule.Type == boxConstant.RuleTypeLogical {
+				report.Drop("logical rules with items unsupported by sing-box rule-set", 1)
+			} else {
+				report.Drop("rules with "+strings.Join(unheadlessItems(rule.DefaultOptions), ", ")+" items unsupported by sing-box rule-set", 1)
+			}
+			continue
+		}
+		headlessRules = append(headlessRules, rule.ToHeadless())
 	}
+	return headlessRules
 }
 
-func filter1100Rule(it option.HeadlessRule) bool {
-	return !hasRule([]option.HeadlessRule{it}, func(it option.DefaultHeadlessRule) bool {
-		return len(it.NetworkType) > 0 || it.NetworkIsExpensive || it.NetworkIsConstrained
-	})
-}
-
-func hasRule(rules []option.HeadlessRule, cond func(rule option.DefaultHeadlessRule) bool) bool {
-	for _, rule := range rules {
-		switch rule.Type {
-		case boxConstant.RuleTypeDefault:
-			if cond(rule.DefaultOptions) {
-				return true
-			}
-		case boxConstant.RuleTypeLogical:
-			if hasRule(rule.LogicalOptions.Rules, cond) {
-				return true
-			}
+// unheadlessItems returns names of the items unsupported by sing-box rule-sets in the rule.
+func unheadlessItems(rule adapter.DefaultRule) []string {
+	var names []string
+	for _, item := range []struct {
+		name  string
+		count int
+	}{
+		{"GEOIP", len(rule.GEOIP)},
+		{"SRC-GEOIP", len(rule.SourceGEOIP)},
+		{"IP-ASN", len(rule.IPASN)},
+		{"SRC-IP-ASN", len(rule.SourceIPASN)},
+		{"IN-NAME", len(rule.Inbound)},
+		{"IN-TYPE", len(rule.InboundType)},
+		{"IN-PORT", len(rule.InboundPort)},
+		{"IN-USER", len(rule.InboundUser)},
+		{"IP-SUFFIX", len(rule.IPSuffix)},
+		{"SRC-IP-SUFFIX", len(rule.SourceIPSuffix)},
+		{"DSCP", len(rule.DSCP)},
+		{"UID", len(rule.UID)},
+		{"RULE-SET", len(rule.RuleSet) + len(rule.DomainSet)},
+	} {
+		if item.count > 0 {
+			names = append(names, item.name)
 		}
 	}
-	return false
+	return names
 }
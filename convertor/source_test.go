@@ -0,0 +1,43 @@
+package convertor
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/ranges"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleSetSourceUnsupportedItems(t *testing.T) {
+	t.Parallel()
+	rules := []adapter.Rule{
+		{Type: boxConstant.RuleTypeDefault, DefaultOptions: adapter.DefaultRule{
+			DefaultHeadlessRule: boxOption.DefaultHeadlessRule{Domain: []string{"example.com"}},
+		}},
+		{Type: boxConstant.RuleTypeDefault, DefaultOptions: adapter.DefaultRule{IPSuffix: []string{"8.8.8.8/24"}}},
+		{Type: boxConstant.RuleTypeDefault, DefaultOptions: adapter.DefaultRule{
+			DSCP: []ranges.Range[uint8]{ranges.New[uint8](4, 4)},
+			UID:  []ranges.Range[uint32]{ranges.New[uint32](1000, 1000)},
+		}},
+		{Type: boxConstant.RuleTypeLogical, LogicalOptions: adapter.LogicalRule{
+			Mode: boxConstant.LogicalTypeAnd,
+			Rules: []adapter.Rule{
+				{Type: boxConstant.RuleTypeDefault, DefaultOptions: adapter.DefaultRule{IPSuffix: []string{"1.1.1.1/8"}}},
+			},
+		}},
+	}
+	var report adapter.ConvertReport
+	content, err := (*RuleSetSource)(nil).To(context.Background(), rules, adapter.ConvertOptions{Report: &report})
+	require.NoError(t, err)
+	require.Contains(t, string(content), "example.com")
+	require.Equal(t, []adapter.ConvertReportItem{
+		{Reason: "rules with IP-SUFFIX items unsupported by sing-box rule-set", Count: 1},
+		{Reason: "rules with DSCP, UID items unsupported by sing-box rule-set", Count: 1},
+		{Reason: "logical rules with items unsupported by sing-box rule-set", Count: 1},
+	}, report.Items())
+	require.Equal(t, 3, report.Dropped())
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sagernet/srsc/adapter"
 	C "github.com/sagernet/srsc/constant"
 	"github.com/sagernet/srsc/convertor/clash"
+	"github.com/sagernet/srsc/option"
 )
 
 var _ adapter.Convertor = (*SurgeRuleSet)(nil)
@@ -32,12 +33,18 @@ func (s *SurgeRuleSet) From(ctx context.Context, content []byte, options adapter
 		var rules []adapter.Rule
 		scanner := bufio.NewScanner(bytes.NewReader(content))
 		for scanner.Scan() {
-			rule, _ := clash.FromSurgeLine(scanner.Text())
-			if rule != nil {
-				rules = append(rules, *rule)
+			ruleLine := strings.TrimSpace(scanner.Text())
+			if ruleLine == "" || strings.HasPrefix(ruleLine, "#") || strings.HasPrefix(ruleLine, "//") {
+				continue
+			}
+			rule, err := clash.FromSurgeLine(ruleLine)
+			if err != nil {
+				options.Report.Drop("rules unsupported by sing-box", 1, ruleLine)
+				continue
 			}
+			rules = append(rules, *rule)
 		}
-		return adapter.MergeRules(rules), nil
+		return adapter.ResolveRuleSets(ctx, adapter.MergeRules(rules), surgeRuleSetURLOptions)
 	case "domain":
 		var rule adapter.DefaultRule
 		scanner := bufio.NewScanner(bytes.NewReader(content))
@@ -70,6 +77,7 @@ func (s *SurgeRuleSet) To(ctx context.Context, contentRules []adapter.Rule, opti
 		for _, rule := range convertedRules {
 			ruleLines, err := clash.ToSurgeLines(rule)
 			if err != nil {
+				options.Report.Drop("rules unsupported by Surge", 1, err.Error())
 				continue
 			}
 			lines = append(lines, ruleLines...)
@@ -79,8 +87,10 @@ func (s *SurgeRuleSet) To(ctx context.Context, contentRules []adapter.Rule, opti
 		var output bytes.Buffer
 		for _, rule := range contentRules {
 			if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+				options.Report.DropRule(rule, "Surge domain set")
 				continue
 			}
+			options.Report.DropDestinationItems(rule.DefaultOptions, "Surge domain set", "domain", "domain_suffix")
 			for _, domain := range rule.DefaultOptions.Domain {
 				output.WriteString(domain + "\n")
 			}
@@ -93,3 +103,14 @@ func (s *SurgeRuleSet) To(ctx context.Context, contentRules []adapter.Rule, opti
 		return nil, E.New("unknown Surge target behavior: " + behavior)
 	}
 }
+
+func surgeRuleSetURLOptions(_ string, domainSet bool) option.SourceConvertOptions {
+	behavior := "classical"
+	if domainSet {
+		behavior = "domain"
+	}
+	return option.SourceConvertOptions{
+		SourceType:   C.ConvertorTypeSurgeRuleSet,
+		SurgeOptions: option.SurgeRuleProviderSourceOptions{SourceBehavior: behavior},
+	}
+}
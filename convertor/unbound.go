@@ -0,0 +1,45 @@
+package convertor
+
+import (
+	"bytes"
+	"context"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.Convertor = (*Unbound)(nil)
+
+// Unbound generates Unbound local zones, domain items are matched with their subdomains,
+// since local zones always include subdomains.
+type Unbound struct{}
+
+func (u *Unbound) Type() string {
+	return C.ConvertorTypeUnbound
+}
+
+func (u *Unbound) ContentType(_ adapter.ConvertOptions) string {
+	return "text/plain"
+}
+
+func (u *Unbound) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return nil, E.New("Unbound configuration can only be used as target")
+}
+
+func (u *Unbound) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	zoneType := options.Options.TargetConvertOptions.UnboundOptions.ZoneType
+	if zoneType == "" {
+		zoneType = "always_nxdomain"
+	}
+	domains, dropped, err := collectDNSDomains(ctx, contentRules, "Unbound", options.Report, false, false)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writeDroppedComments(&output, dropped)
+	for _, domain := range domains {
+		output.WriteString("local-zone: \"" + domain.Name + ".\" " + zoneType + "\n")
+	}
+	return output.Bytes(), nil
+}
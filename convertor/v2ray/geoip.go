@@ -0,0 +1,88 @@
+package v2ray
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.BundleConvertor = (*GeoIPList)(nil)
+
+type GeoIPList struct{}
+
+func (g *GeoIPList) Type() string {
+	return C.ConvertorTypeV2RayGeoIP
+}
+
+func (g *GeoIPList) ContentType(_ adapter.ConvertOptions) string {
+	return "application/octet-stream"
+}
+
+func (g *GeoIPList) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	code := sourceCode(options)
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	geoIP, err := ReadGeoIP(content, code)
+	if err != nil {
+		return nil, err
+	}
+	var rule adapter.DefaultRule
+	for _, cidr := range geoIP.CIDR {
+		address, ok := netip.AddrFromSlice(cidr.IP)
+		if !ok {
+			return nil, E.New("invalid IP address length: ", len(cidr.IP))
+		}
+		prefix, err := address.Unmap().Prefix(int(cidr.Prefix))
+		if err != nil {
+			return nil, err
+		}
+		rule.IPCIDR = append(rule.IPCIDR, prefix.String())
+	}
+	rule.Invert = geoIP.ReverseMatch
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (g *GeoIPList) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	code := targetCode(options)
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	return g.ToBundle(ctx, []adapter.RuleCategory{{Name: code, Rules: contentRules}}, options)
+}
+
+func (g *GeoIPList) ToBundle(ctx context.Context, categories []adapter.RuleCategory, options adapter.ConvertOptions) ([]byte, error) {
+	geoIPList := make([]GeoIP, 0, len(categories))
+	for _, category := range categories {
+		convertedRules, err := adapter.EmbedResourceRules(ctx, category.Rules)
+		if err != nil {
+			return nil, err
+		}
+		geoIP := GeoIP{Code: strings.ToUpper(category.Name)}
+		for _, rule := range convertedRules {
+			if rule.Type != boxConstant.RuleTypeDefault || rule.DefaultOptions.Invert || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+				options.Report.DropRule(rule, "V2Ray geoip")
+				continue
+			}
+			options.Report.DropDestinationItems(rule.DefaultOptions, "V2Ray geoip", "ip_cidr")
+			for _, prefixString := range rule.DefaultOptions.IPCIDR {
+				prefix, err := netip.ParsePrefix(prefixString)
+				if err != nil {
+					address, addrErr := netip.ParseAddr(prefixString)
+					if addrErr != nil {
+						return nil, E.Cause(err, "parse IP CIDR: ", prefixString)
+					}
+					prefix = netip.PrefixFrom(address, address.BitLen())
+				}
+				geoIP.CIDR = append(geoIP.CIDR, CIDR{IP: prefix.Addr().AsSlice(), Prefix: uint32(prefix.Bits())})
+			}
+		}
+		geoIPList = append(geoIPList, geoIP)
+	}
+	return WriteGeoIPList(geoIPList), nil
+}
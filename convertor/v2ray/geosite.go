@@ -0,0 +1,131 @@
+package v2ray
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+var _ adapter.BundleConvertor = (*GeoSiteList)(nil)
+
+type GeoSiteList struct{}
+
+func (g *GeoSiteList) Type() string {
+	return C.ConvertorTypeV2RayGeoSite
+}
+
+func (g *GeoSiteList) ContentType(_ adapter.ConvertOptions) string {
+	return "application/octet-stream"
+}
+
+func (g *GeoSiteList) From(ctx context.Context, content []byte, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	code := sourceCode(options)
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	codeParts := strings.Split(code, "@")
+	geoSite, err := ReadGeoSite(content, codeParts[0])
+	if err != nil {
+		return nil, err
+	}
+	var rule adapter.DefaultRule
+	for _, domain := range geoSite.Domain {
+		if !matchAttributes(domain, codeParts[1:]) {
+			continue
+		}
+		switch domain.Type {
+		case DomainTypePlain:
+			rule.DomainKeyword = append(rule.DomainKeyword, domain.Value)
+		case DomainTypeRegex:
+			rule.DomainRegex = append(rule.DomainRegex, domain.Value)
+		case DomainTypeRootDomain:
+			rule.DomainSuffix = append(rule.DomainSuffix, domain.Value)
+		case DomainTypeFull:
+			rule.Domain = append(rule.Domain, domain.Value)
+		default:
+			return nil, E.New("unknown domain type: ", domain.Type)
+		}
+	}
+	return []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, nil
+}
+
+func (g *GeoSiteList) To(ctx context.Context, contentRules []adapter.Rule, options adapter.ConvertOptions) ([]byte, error) {
+	code := targetCode(options)
+	if code == "" {
+		return nil, E.New("missing code")
+	}
+	return g.ToBundle(ctx, []adapter.RuleCategory{{Name: code, Rules: contentRules}}, options)
+}
+
+func (g *GeoSiteList) ToBundle(ctx context.Context, categories []adapter.RuleCategory, options adapter.ConvertOptions) ([]byte, error) {
+	geoSiteList := make([]GeoSite, 0, len(categories))
+	for _, category := range categories {
+		convertedRules, err := adapter.EmbedResourceRules(ctx, category.Rules)
+		if err != nil {
+			return nil, err
+		}
+		geoSite := GeoSite{Code: strings.ToUpper(category.Name)}
+		for _, rule := range convertedRules {
+			if rule.Type != boxConstant.RuleTypeDefault || rule.DefaultOptions.Invert || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+				options.Report.DropRule(rule, "V2Ray geosite")
+				continue
+			}
+			options.Report.DropDestinationItems(rule.DefaultOptions, "V2Ray geosite", "domain", "domain_suffix", "domain_keyword", "domain_regex")
+			for _, domain := range rule.DefaultOptions.Domain {
+				geoSite.Domain = append(geoSite.Domain, Domain{Type: DomainTypeFull, Value: domain})
+			}
+			for _, domainSuffix := range rule.DefaultOptions.DomainSuffix {
+				if strings.HasPrefix(domainSuffix, ".") {
+					// root domains also match the domain itself.
+					geoSite.Domain = append(geoSite.Domain, Domain{Type: DomainTypeRegex, Value: regexp.QuoteMeta(domainSuffix) + "$"})
+				} else {
+					geoSite.Domain = append(geoSite.Domain, Domain{Type: DomainTypeRootDomain, Value: domainSuffix})
+				}
+			}
+			for _, domainKeyword := range rule.DefaultOptions.DomainKeyword {
+				geoSite.Domain = append(geoSite.Domain, Domain{Type: DomainTypePlain, Value: domainKeyword})
+			}
+			for _, domainRegex := range rule.DefaultOptions.DomainRegex {
+				geoSite.Domain = append(geoSite.Domain, Domain{Type: DomainTypeRegex, Value: domainRegex})
+			}
+		}
+		geoSiteList = append(geoSiteList, geoSite)
+	}
+	return WriteGeoSiteList(geoSiteList), nil
+}
+
+func sourceCode(options adapter.ConvertOptions) string {
+	if options.Options.SourceConvertOptions.V2RayOptions.Code != "" {
+		return options.Options.SourceConvertOptions.V2RayOptions.Code
+	}
+	return options.Params["code"]
+}
+
+func targetCode(options adapter.ConvertOptions) string {
+	if options.Options.TargetConvertOptions.V2RayOptions.Code != "" {
+		return options.Options.TargetConvertOptions.V2RayOptions.Code
+	}
+	return options.Params["code"]
+}
+
+// matchAttributes checks attribute filters like `@cn` and `@!cn`.
+func matchAttributes(domain Domain, attributes []string) bool {
+	for _, attribute := range attributes {
+		attribute = strings.ToLower(attribute)
+		exclude := strings.HasPrefix(attribute, "!")
+		attribute = strings.TrimPrefix(attribute, "!")
+		hasAttribute := common.Any(domain.Attribute, func(it Attribute) bool {
+			return strings.EqualFold(it.Key, attribute)
+		})
+		if hasAttribute == exclude {
+			return false
+		}
+	}
+	return true
+}
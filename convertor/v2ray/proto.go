@@ -0,0 +1,246 @@
+package v2ray
+
+import (
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf messages from v2ray-core/app/router/routercommon/common.proto
+
+const (
+	DomainTypePlain = iota
+	DomainTypeRegex
+	DomainTypeRootDomain
+	DomainTypeFull
+)
+
+type Domain struct {
+	Type      int
+	Value     string
+	Attribute []Attribute
+}
+
+type Attribute struct {
+	Key       string
+	BoolValue bool
+	IntValue  int64
+}
+
+type GeoSite struct {
+	Code   string
+	Domain []Domain
+}
+
+type CIDR struct {
+	IP     []byte
+	Prefix uint32
+}
+
+type GeoIP struct {
+	Code         string
+	CIDR         []CIDR
+	ReverseMatch bool
+}
+
+type fieldVisitor func(number protowire.Number, wireType protowire.Type, value []byte) error
+
+func readMessage(content []byte, visitor fieldVisitor) error {
+	for len(content) > 0 {
+		number, wireType, n := protowire.ConsumeTag(content)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		content = content[n:]
+		n = protowire.ConsumeFieldValue(number, wireType, content)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		err := visitor(number, wireType, content[:n])
+		if err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	return nil
+}
+
+func readBytes(value []byte) []byte {
+	content, _ := protowire.ConsumeBytes(value)
+	return content
+}
+
+func readVarint(value []byte) uint64 {
+	content, _ := protowire.ConsumeVarint(value)
+	return content
+}
+
+// findEntry returns the first entry of GeoSiteList or GeoIPList with the given country code.
+func findEntry(content []byte, code string) ([]byte, error) {
+	var entry []byte
+	err := readMessage(content, func(number protowire.Number, wireType protowire.Type, value []byte) error {
+		if entry != nil || number != 1 || wireType != protowire.BytesType {
+			return nil
+		}
+		entryContent := readBytes(value)
+		return readMessage(entryContent, func(number protowire.Number, wireType protowire.Type, value []byte) error {
+			if number == 1 && wireType == protowire.BytesType && strings.EqualFold(string(readBytes(value)), code) {
+				entry = entryContent
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, E.New("code not found: ", code)
+	}
+	return entry, nil
+}
+
+func ReadGeoSite(content []byte, code string) (*GeoSite, error) {
+	entry, err := findEntry(content, code)
+	if err != nil {
+		return nil, err
+	}
+	var geoSite GeoSite
+	err = readMessage(entry, func(number protowire.Number, wireType protowire.Type, value []byte) error {
+		switch number {
+		case 1:
+			geoSite.Code = string(readBytes(value))
+		case 2:
+			domain, err := readDomain(readBytes(value))
+			if err != nil {
+				return err
+			}
+			geoSite.Domain = append(geoSite.Domain, domain)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &geoSite, nil
+}
+
+func readDomain(content []byte) (Domain, error) {
+	var domain Domain
+	err := readMessage(content, func(number protowire.Number, wireType protowire.Type, value []byte) error {
+		switch number {
+		case 1:
+			domain.Type = int(readVarint(value))
+		case 2:
+			domain.Value = string(readBytes(value))
+		case 3:
+			var attribute Attribute
+			err := readMessage(readBytes(value), func(number protowire.Number, wireType protowire.Type, value []byte) error {
+				switch number {
+				case 1:
+					attribute.Key = string(readBytes(value))
+				case 2:
+					attribute.BoolValue = readVarint(value) != 0
+				case 3:
+					attribute.IntValue = int64(readVarint(value))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			domain.Attribute = append(domain.Attribute, attribute)
+		}
+		return nil
+	})
+	return domain, err
+}
+
+func ReadGeoIP(content []byte, code string) (*GeoIP, error) {
+	entry, err := findEntry(content, code)
+	if err != nil {
+		return nil, err
+	}
+	var geoIP GeoIP
+	err = readMessage(entry, func(number protowire.Number, wireType protowire.Type, value []byte) error {
+		switch number {
+		case 1:
+			geoIP.Code = string(readBytes(value))
+		case 2:
+			var cidr CIDR
+			err := readMessage(readBytes(value), func(number protowire.Number, wireType protowire.Type, value []byte) error {
+				switch number {
+				case 1:
+					cidr.IP = readBytes(value)
+				case 2:
+					cidr.Prefix = uint32(readVarint(value))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			geoIP.CIDR = append(geoIP.CIDR, cidr)
+		case 3:
+			geoIP.ReverseMatch = readVarint(value) != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &geoIP, nil
+}
+
+func appendMessage(b []byte, number protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func appendString(b []byte, number protowire.Number, value string) []byte {
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendVarint(b []byte, number protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, number, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+func WriteGeoSiteList(geoSiteList []GeoSite) []byte {
+	var content []byte
+	for _, geoSite := range geoSiteList {
+		var entry []byte
+		entry = appendString(entry, 1, geoSite.Code)
+		for _, domain := range geoSite.Domain {
+			var domainContent []byte
+			if domain.Type != DomainTypePlain {
+				domainContent = appendVarint(domainContent, 1, uint64(domain.Type))
+			}
+			domainContent = appendString(domainContent, 2, domain.Value)
+			entry = appendMessage(entry, 2, domainContent)
+		}
+		content = appendMessage(content, 1, entry)
+	}
+	return content
+}
+
+func WriteGeoIPList(geoIPList []GeoIP) []byte {
+	var content []byte
+	for _, geoIP := range geoIPList {
+		var entry []byte
+		entry = appendString(entry, 1, geoIP.Code)
+		for _, cidr := range geoIP.CIDR {
+			var cidrContent []byte
+			cidrContent = appendMessage(cidrContent, 1, cidr.IP)
+			cidrContent = appendVarint(cidrContent, 2, uint64(cidr.Prefix))
+			entry = appendMessage(entry, 2, cidrContent)
+		}
+		if geoIP.ReverseMatch {
+			entry = appendVarint(entry, 3, 1)
+		}
+		content = appendMessage(content, 1, entry)
+	}
+	return content
+}
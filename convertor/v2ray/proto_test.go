@@ -0,0 +1,105 @@
+package v2ray
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encodeDomain(domainType int, value string, attributes ...string) []byte {
+	var domain []byte
+	domain = appendVarint(domain, 1, uint64(domainType))
+	domain = appendString(domain, 2, value)
+	for _, attribute := range attributes {
+		domain = appendMessage(domain, 3, appendVarint(appendString(nil, 1, attribute), 2, 1))
+	}
+	return domain
+}
+
+func TestGeoSite(t *testing.T) {
+	t.Parallel()
+	var entry []byte
+	entry = appendString(entry, 1, "EXAMPLE")
+	entry = appendMessage(entry, 2, encodeDomain(DomainTypeRootDomain, "example.com", "cn"))
+	entry = appendMessage(entry, 2, encodeDomain(DomainTypeFull, "www.example.org"))
+	entry = appendMessage(entry, 2, encodeDomain(DomainTypePlain, "example"))
+	entry = appendMessage(entry, 2, encodeDomain(DomainTypeRegex, `^example\.net$`))
+	var content []byte
+	content = appendMessage(content, 1, appendString(nil, 1, "OTHER"))
+	content = appendMessage(content, 1, entry)
+	rules, err := (*GeoSiteList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "example"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"www.example.org"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"example"}, rules[0].DefaultOptions.DomainKeyword)
+	require.Equal(t, badoption.Listable[string]{`^example\.net$`}, rules[0].DefaultOptions.DomainRegex)
+	rules, err = (*GeoSiteList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "example@cn"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Empty(t, rules[0].DefaultOptions.Domain)
+	_, err = (*GeoSiteList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "missing"},
+	})
+	require.Error(t, err)
+}
+
+func TestGeoIP(t *testing.T) {
+	t.Parallel()
+	var entry []byte
+	entry = appendString(entry, 1, "private")
+	entry = appendMessage(entry, 2, appendVarint(appendMessage(nil, 1, []byte{10, 0, 0, 0}), 2, 8))
+	entry = appendMessage(entry, 2, appendVarint(appendMessage(nil, 1, []byte{0xfc, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}), 2, 7))
+	content := appendMessage(nil, 1, entry)
+	rules, err := (*GeoIPList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "PRIVATE"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/8", "fc00::/7"}, rules[0].DefaultOptions.IPCIDR)
+}
+
+func TestGeoSiteTo(t *testing.T) {
+	t.Parallel()
+	var rule adapter.DefaultRule
+	rule.Domain = []string{"example.com"}
+	rule.DomainSuffix = []string{"example.net", ".example.org"}
+	rule.DomainKeyword = []string{"example"}
+	content, err := (*GeoSiteList)(nil).ToBundle(context.Background(), []adapter.RuleCategory{
+		{Name: "example", Rules: []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}},
+		{Name: "other"},
+	}, adapter.ConvertOptions{})
+	require.NoError(t, err)
+	rules, err := (*GeoSiteList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "example"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.net"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"example"}, rules[0].DefaultOptions.DomainKeyword)
+	require.Equal(t, badoption.Listable[string]{`\.example\.org$`}, rules[0].DefaultOptions.DomainRegex)
+}
+
+func TestGeoIPTo(t *testing.T) {
+	t.Parallel()
+	var rule adapter.DefaultRule
+	rule.IPCIDR = []string{"10.0.0.0/8", "fc00::/7", "1.1.1.1"}
+	content, err := (*GeoIPList)(nil).To(context.Background(), []adapter.Rule{{Type: boxConstant.RuleTypeDefault, DefaultOptions: rule}}, adapter.ConvertOptions{
+		Params: map[string]string{"code": "private"},
+	})
+	require.NoError(t, err)
+	rules, err := (*GeoIPList)(nil).From(context.Background(), content, adapter.ConvertOptions{
+		Params: map[string]string{"code": "PRIVATE"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/8", "fc00::/7", "1.1.1.1/32"}, rules[0].DefaultOptions.IPCIDR)
+}
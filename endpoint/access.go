@@ -0,0 +1,152 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/srsc/option"
+)
+
+// AccessControl restricts access to endpoints by source IP and credentials,
+// requests must come from an allowed IP, and carry a token or a user if any is configured.
+type AccessControl struct {
+	logger   logger.ContextLogger
+	tokens   []string
+	users    map[string]string
+	allowIPs []netip.Prefix
+}
+
+func NewAccessControl(logger logger.ContextLogger, options option.AccessOptions) (*AccessControl, error) {
+	access := &AccessControl{
+		logger: logger,
+	}
+	for i, secret := range options.Tokens {
+		token, err := secret.Load()
+		if err != nil {
+			return nil, E.Cause(err, "load tokens[", i, "]")
+		}
+		access.tokens = append(access.tokens, token)
+	}
+	if len(options.Users) > 0 {
+		access.users = make(map[string]string)
+		for i, user := range options.Users {
+			if user.Username == "" {
+				return nil, E.New("users[", i, "]: missing username")
+			}
+			password, err := user.Password.Load()
+			if err != nil {
+				return nil, E.Cause(err, "load users[", i, "].password")
+			}
+			access.users[user.Username] = password
+		}
+	}
+	for _, prefix := range options.AllowIPs {
+		access.allowIPs = append(access.allowIPs, prefix.Build(netip.Prefix{}))
+	}
+	return access, nil
+}
+
+// Authenticates returns whether tokens or users are required.
+func (a *AccessControl) Authenticates() bool {
+	return len(a.tokens) > 0 || len(a.users) > 0
+}
+
+// Handler returns the handler checking access before calling next,
+// the token query parameter is removed so that it is not logged or passed to next.
+func (a *AccessControl) Handler(next http.HandlerFunc) http.HandlerFunc {
+	if len(a.tokens) == 0 && len(a.users) == 0 && len(a.allowIPs) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		queryToken := query.Get("token")
+		if query.Has("token") {
+			query.Del("token")
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = query.Encode()
+		}
+		identity, status, err := a.check(r, queryToken)
+		if err != nil {
+			if status == http.StatusUnauthorized && len(a.users) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="srsc", charset="UTF-8"`)
+			}
+			w.WriteHeader(status)
+			a.logger.Warn("denied ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", err)
+			return
+		}
+		if identity != "" {
+			r = r.WithContext(context.WithValue(r.Context(), (*accessIdentityKey)(nil), identity))
+		}
+		next(w, r)
+	}
+}
+
+type accessIdentityKey struct{}
+
+// accessIdentity returns the token or user authenticated by access control, or empty if not authenticated.
+func accessIdentity(ctx context.Context) string {
+	identity, _ := ctx.Value((*accessIdentityKey)(nil)).(string)
+	return identity
+}
+
+func (a *AccessControl) check(r *http.Request, queryToken string) (string, int, error) {
+	if len(a.allowIPs) > 0 {
+		sourceAddr := M.ParseSocksaddr(r.RemoteAddr).Addr.Unmap()
+		if !a.isAllowedIP(sourceAddr) {
+			return "", http.StatusForbidden, E.New("source IP not allowed: ", sourceAddr)
+		}
+	}
+	if len(a.tokens) == 0 && len(a.users) == 0 {
+		return "", 0, nil
+	}
+	authorization := r.Header.Get("Authorization")
+	if token, isBearer := strings.CutPrefix(authorization, "Bearer "); isBearer {
+		if index := a.tokenIndex(token); index >= 0 {
+			return F.ToString("token.", index), 0, nil
+		}
+	}
+	if queryToken != "" {
+		if index := a.tokenIndex(queryToken); index >= 0 {
+			return F.ToString("token.", index), 0, nil
+		}
+	}
+	if username, password, isBasic := r.BasicAuth(); isBasic && a.isUser(username, password) {
+		return "user." + username, 0, nil
+	}
+	if authorization == "" && queryToken == "" {
+		return "", http.StatusUnauthorized, E.New("missing credentials")
+	}
+	return "", http.StatusUnauthorized, E.New("invalid credentials")
+}
+
+func (a *AccessControl) isAllowedIP(addr netip.Addr) bool {
+	for _, prefix := range a.allowIPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenIndex returns the index of the token, or -1 if not found.
+func (a *AccessControl) tokenIndex(token string) int {
+	index := -1
+	for i, expected := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			index = i
+		}
+	}
+	return index
+}
+
+func (a *AccessControl) isUser(username string, password string) bool {
+	expected, loaded := a.users[username]
+	return loaded && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
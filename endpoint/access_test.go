@@ -0,0 +1,56 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessControl(t *testing.T) {
+	t.Parallel()
+	access, err := NewAccessControl(logger.NOP(), option.AccessOptions{
+		Tokens: []option.Secret{{Value: "token"}},
+		Users:  []option.AccessUser{{Username: "user", Password: option.Secret{Value: "password"}}},
+		AllowIPs: badoption.Listable[*badoption.Prefixable]{
+			common.Ptr(badoption.Prefixable(netip.MustParsePrefix("127.0.0.0/8"))),
+		},
+	})
+	require.NoError(t, err)
+	var rawQuery string
+	handler := access.Handler(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+	})
+	serve := func(target string, remoteAddr string, setup func(r *http.Request)) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		request.RemoteAddr = remoteAddr
+		if setup != nil {
+			setup(request)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+	recorder := serve("/test", "127.0.0.1:1234", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("WWW-Authenticate"))
+	require.Equal(t, http.StatusOK, serve("/test", "127.0.0.1:1234", func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer token")
+	}).Code)
+	require.Equal(t, http.StatusOK, serve("/test", "127.0.0.1:1234", func(r *http.Request) {
+		r.SetBasicAuth("user", "password")
+	}).Code)
+	require.Equal(t, http.StatusUnauthorized, serve("/test", "127.0.0.1:1234", func(r *http.Request) {
+		r.SetBasicAuth("user", "token")
+	}).Code)
+	require.Equal(t, http.StatusOK, serve("/test?token=token&a=b", "127.0.0.1:1234", nil).Code)
+	require.Equal(t, "a=b", rawQuery)
+	require.Equal(t, http.StatusForbidden, serve("/test?token=token", "192.0.2.1:1234", nil).Code)
+}
@@ -0,0 +1,190 @@
+package endpoint
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SourceRefresher is implemented by endpoints to fetch their sources ignoring the cache.
+type SourceRefresher interface {
+	RefreshSources(urlParams map[string]string) ([]string, error)
+}
+
+// AdminEndpoint serves the admin API for inspecting and purging the cache, and refreshing sources.
+type AdminEndpoint struct {
+	logger    logger.ContextLogger
+	cache     adapter.Cache
+	resources adapter.ResourceManager
+	router    *chi.Mux
+	endpoints map[string]SourceRefresher
+}
+
+type AdminCacheEntry struct {
+	Key         string    `json:"key"`
+	Size        int       `json:"size"`
+	ETag        string    `json:"etag,omitempty"`
+	LastUpdated time.Time `json:"last_updated"`
+	Age         int64     `json:"age"`
+}
+
+type AdminPurgeResult struct {
+	Deleted int `json:"deleted"`
+}
+
+type AdminRefreshResult struct {
+	Sources []string `json:"sources"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+func NewAdminEndpoint(logger logger.ContextLogger, cache adapter.Cache, resources adapter.ResourceManager, router *chi.Mux) *AdminEndpoint {
+	return &AdminEndpoint{
+		logger:    logger,
+		cache:     cache,
+		resources: resources,
+		router:    router,
+		endpoints: make(map[string]SourceRefresher),
+	}
+}
+
+// AddEndpoint registers the endpoint of the routing pattern for refreshing by path.
+func (a *AdminEndpoint) AddEndpoint(pattern string, endpoint SourceRefresher) {
+	a.endpoints[pattern] = endpoint
+}
+
+// ListCache lists cached entries of keys starting with the prefix query parameter.
+func (a *AdminEndpoint) ListCache(w http.ResponseWriter, r *http.Request) {
+	entries, err := a.cache.List(r.URL.Query().Get("prefix"))
+	if err != nil {
+		a.writeError(w, r, http.StatusInternalServerError, E.Cause(err, "list cache"))
+		return
+	}
+	now := time.Now()
+	cacheEntries := make([]AdminCacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		cacheEntries = append(cacheEntries, AdminCacheEntry{
+			Key:         entry.Tag,
+			Size:        entry.Size,
+			ETag:        entry.Binary.LastEtag,
+			LastUpdated: entry.Binary.LastUpdated,
+			Age:         int64(now.Sub(entry.Binary.LastUpdated).Seconds()),
+		})
+	}
+	sort.Slice(cacheEntries, func(i, j int) bool {
+		return cacheEntries[i].Key < cacheEntries[j].Key
+	})
+	writeJSON(w, http.StatusOK, cacheEntries)
+}
+
+// PurgeCache deletes the cached entry of the key query parameter, or entries of keys starting with the prefix query parameter.
+func (a *AdminEndpoint) PurgeCache(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("key")
+	if key == "" && !query.Has("prefix") {
+		a.writeError(w, r, http.StatusBadRequest, E.New("missing key or prefix"))
+		return
+	}
+	if key != "" {
+		// the entry is loaded only to count the deletion.
+		binary, err := a.cache.LoadBinary(key)
+		exists := binary != nil || err != nil
+		err = a.cache.Delete(key)
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, E.Cause(err, "delete ", key))
+			return
+		}
+		var deleted int
+		if exists {
+			deleted = 1
+		}
+		a.logger.Info("purged ", deleted, " cache entries of ", key)
+		writeJSON(w, http.StatusOK, AdminPurgeResult{Deleted: deleted})
+		return
+	}
+	prefix := query.Get("prefix")
+	entries, err := a.cache.List(prefix)
+	if err != nil {
+		a.writeError(w, r, http.StatusInternalServerError, E.Cause(err, "list cache"))
+		return
+	}
+	var deleted int
+	for _, entry := range entries {
+		err = a.cache.Delete(entry.Tag)
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, E.Cause(err, "delete ", entry.Tag))
+			return
+		}
+		deleted++
+	}
+	a.logger.Info("purged ", deleted, " cache entries of ", prefix)
+	writeJSON(w, http.StatusOK, AdminPurgeResult{Deleted: deleted})
+}
+
+// Refresh fetches sources of the endpoint of the path query parameter,
+// or the resource of the resource and code query parameters, ignoring the cache.
+func (a *AdminEndpoint) Refresh(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var (
+		sources []string
+		err     error
+	)
+	if path := query.Get("path"); path != "" {
+		routeContext := chi.NewRouteContext()
+		pattern := a.router.Find(routeContext, http.MethodGet, path)
+		endpoint, loaded := a.endpoints[pattern]
+		if !loaded {
+			a.writeError(w, r, http.StatusNotFound, E.New("endpoint not found: ", path))
+			return
+		}
+		var urlParams map[string]string
+		if len(routeContext.URLParams.Keys) > 0 {
+			urlParams = make(map[string]string)
+			for i, key := range routeContext.URLParams.Keys {
+				urlParams[key] = routeContext.URLParams.Values[i]
+			}
+		}
+		sources, err = endpoint.RefreshSources(urlParams)
+	} else if resource := query.Get("resource"); resource != "" {
+		code := query.Get("code")
+		if code == "" {
+			a.writeError(w, r, http.StatusBadRequest, E.New("missing code"))
+			return
+		}
+		var sourcePath string
+		sourcePath, err = a.resources.Refresh(resource, code)
+		sources = []string{sourcePath}
+	} else {
+		a.writeError(w, r, http.StatusBadRequest, E.New("missing path or resource"))
+		return
+	}
+	if err != nil {
+		a.writeError(w, r, http.StatusBadGateway, E.Cause(err, "refresh"))
+		return
+	}
+	a.logger.Info("refreshed ", strings.Join(sources, ", "))
+	writeJSON(w, http.StatusOK, AdminRefreshResult{Sources: sources})
+}
+
+func (a *AdminEndpoint) writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	a.logger.Error("handle ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", err)
+	writeJSON(w, status, adminError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
@@ -0,0 +1,92 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/cache"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type testSourceRefresher map[string]string
+
+func (r testSourceRefresher) RefreshSources(urlParams map[string]string) ([]string, error) {
+	return []string{r[urlParams["name"]]}, nil
+}
+
+type testResourceManager struct {
+	adapter.ResourceManager
+	loadErr error
+}
+
+func (m testResourceManager) CheckLoaded() error {
+	return m.loadErr
+}
+
+func (m testResourceManager) Refresh(resource string, code string) (string, error) {
+	if resource != "geoip" {
+		return "", E.New("unknown resource: ", resource)
+	}
+	return "geoip/" + code + ".json", nil
+}
+
+func TestAdminEndpoint(t *testing.T) {
+	t.Parallel()
+	memoryCache := cache.NewMemory(0)
+	for _, tag := range []string{"source.a", "source.b", "file.0.a"} {
+		require.NoError(t, memoryCache.SaveBinary(tag, &adapter.SavedBinary{
+			Content:     []byte(tag),
+			LastUpdated: time.Now(),
+			LastEtag:    "etag",
+		}))
+	}
+	router := chi.NewRouter()
+	router.Get("/rules/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	admin := NewAdminEndpoint(logger.NOP(), memoryCache, testResourceManager{}, router)
+	admin.AddEndpoint("/rules/{name}", testSourceRefresher{"a": "a.json"})
+	request := func(handler http.HandlerFunc, method string, target string, result any) int {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(method, target, nil))
+		if result != nil {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), result))
+		}
+		return recorder.Code
+	}
+
+	var entries []AdminCacheEntry
+	require.Equal(t, http.StatusOK, request(admin.ListCache, http.MethodGet, "/admin/cache?prefix=source.", &entries))
+	require.Len(t, entries, 2)
+	require.Equal(t, "source.a", entries[0].Key)
+	require.Equal(t, len("source.a"), entries[0].Size)
+	require.Equal(t, "etag", entries[0].ETag)
+	require.Equal(t, "source.b", entries[1].Key)
+
+	var purgeResult AdminPurgeResult
+	require.Equal(t, http.StatusBadRequest, request(admin.PurgeCache, http.MethodDelete, "/admin/cache", nil))
+	require.Equal(t, http.StatusOK, request(admin.PurgeCache, http.MethodDelete, "/admin/cache?key=source.", &purgeResult))
+	require.Equal(t, 0, purgeResult.Deleted)
+	require.Equal(t, http.StatusOK, request(admin.PurgeCache, http.MethodDelete, "/admin/cache?key=source.a", &purgeResult))
+	require.Equal(t, 1, purgeResult.Deleted)
+	require.Equal(t, http.StatusOK, request(admin.PurgeCache, http.MethodDelete, "/admin/cache?prefix=", &purgeResult))
+	require.Equal(t, 2, purgeResult.Deleted)
+	require.Equal(t, http.StatusOK, request(admin.ListCache, http.MethodGet, "/admin/cache", &entries))
+	require.Empty(t, entries)
+
+	var refreshResult AdminRefreshResult
+	require.Equal(t, http.StatusOK, request(admin.Refresh, http.MethodPost, "/admin/refresh?path=/rules/a", &refreshResult))
+	require.Equal(t, []string{"a.json"}, refreshResult.Sources)
+	require.Equal(t, http.StatusNotFound, request(admin.Refresh, http.MethodPost, "/admin/refresh?path=/unknown", nil))
+	require.Equal(t, http.StatusOK, request(admin.Refresh, http.MethodPost, "/admin/refresh?resource=geoip&code=cn", &refreshResult))
+	require.Equal(t, []string{"geoip/cn.json"}, refreshResult.Sources)
+	require.Equal(t, http.StatusBadRequest, request(admin.Refresh, http.MethodPost, "/admin/refresh?resource=geoip", nil))
+	require.Equal(t, http.StatusBadGateway, request(admin.Refresh, http.MethodPost, "/admin/refresh?resource=unknown&code=cn", nil))
+	require.Equal(t, http.StatusBadRequest, request(admin.Refresh, http.MethodPost, "/admin/refresh", nil))
+}
@@ -0,0 +1,208 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var _ http.Handler = (*BundleEndpoint)(nil)
+
+type BundleEndpoint struct {
+	ctx             context.Context
+	logger          logger.ContextLogger
+	cache           adapter.Cache
+	reportStore     adapter.ConvertReportStore
+	metrics         adapter.Metrics
+	index           int
+	targetConvertor adapter.BundleConvertor
+	convertOptions  option.ConvertOptions
+	categories      []bundleCategory
+}
+
+type bundleCategory struct {
+	name      string
+	endpoint  *FileEndpoint
+	urlParams map[string]string
+}
+
+// NewBundleEndpoint creates a endpoint that bundles file endpoints as categories of one file,
+// categories are resolved by matching their paths against the file endpoints registered in the router.
+func NewBundleEndpoint(ctx context.Context, logger logger.ContextLogger, index int, options option.BundleEndpoint, router *chi.Mux, fileEndpoints map[string]*FileEndpoint) (*BundleEndpoint, error) {
+	ep := &BundleEndpoint{
+		ctx:         ctx,
+		logger:      logger,
+		cache:       service.FromContext[adapter.Cache](ctx),
+		reportStore: service.FromContext[adapter.ConvertReportStore](ctx),
+		metrics:     service.FromContext[adapter.Metrics](ctx),
+		index:       index,
+		convertOptions: option.ConvertOptions{
+			TargetConvertOptions: options.TargetOptions,
+		},
+	}
+	targetConvertor, loaded := convertor.Convertors[options.TargetOptions.TargetType]
+	if !loaded {
+		return nil, E.New("unknown target type: ", options.TargetOptions.TargetType)
+	}
+	bundleConvertor, isBundle := targetConvertor.(adapter.BundleConvertor)
+	if !isBundle {
+		return nil, E.New("target type does not support bundle: ", options.TargetOptions.TargetType)
+	}
+	ep.targetConvertor = bundleConvertor
+	if options.Categories == nil || options.Categories.Size() == 0 {
+		return nil, E.New("missing categories")
+	}
+	for _, entry := range options.Categories.Entries() {
+		fileEndpoint, urlParams, err := findFileEndpoint(router, fileEndpoints, entry.Value)
+		if err != nil {
+			return nil, E.Cause(err, "category ", entry.Key)
+		}
+		ep.categories = append(ep.categories, bundleCategory{
+			name:      entry.Key,
+			endpoint:  fileEndpoint,
+			urlParams: urlParams,
+		})
+	}
+	return ep, nil
+}
+
+// RefreshSources fetches sources of referenced file endpoints ignoring the cache.
+func (b *BundleEndpoint) RefreshSources(_ map[string]string) ([]string, error) {
+	var sourcePaths []string
+	for _, category := range b.categories {
+		refreshedPaths, err := category.endpoint.RefreshSources(category.urlParams)
+		if err != nil {
+			return nil, E.Cause(err, "category ", category.name)
+		}
+		sourcePaths = append(sourcePaths, refreshedPaths...)
+	}
+	return sourcePaths, nil
+}
+
+func (b *BundleEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := b.serveHTTP0(w, r)
+	if err != nil {
+		b.logger.Error("handle ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", err)
+	} else {
+		b.logger.Debug("accepted ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\"")
+	}
+}
+
+func (b *BundleEndpoint) serveHTTP0(w http.ResponseWriter, r *http.Request) error {
+	metadata, err := detectMetadata(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	convertOptions := adapter.ConvertOptions{
+		Options:  b.convertOptions,
+		Metadata: metadata,
+	}
+	sourceBinaries := make([]*adapter.SavedBinary, 0, len(b.categories))
+	var lastUpdated time.Time
+	hash := sha256.New()
+	for _, category := range b.categories {
+		sourceBinary, err := category.endpoint.FetchSource(category.urlParams)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return E.Cause(err, "fetch category ", category.name)
+		}
+		sourceBinaries = append(sourceBinaries, sourceBinary)
+		if sourceBinary.LastUpdated.After(lastUpdated) {
+			lastUpdated = sourceBinary.LastUpdated
+		}
+		sourceDigest, err := category.endpoint.SourceDigest(sourceBinary, category.urlParams)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return E.Cause(err, "fetch category ", category.name)
+		}
+		hash.Write([]byte(F.ToString(category.name, "\n", sourceDigest, "\n")))
+	}
+	bundleDigest := hex.EncodeToString(hash.Sum(nil)[:16])
+	bundleEtag := "\"" + bundleDigest + "\""
+	cacheKey := cacheKeyPrefix(b.ctx, C.EndpointTypeBundle, b.index)
+	cachedBinary, err := b.cache.LoadBinary(cacheKey)
+	if err != nil && !os.IsNotExist(err) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "load cache binary")
+	}
+	if cachedBinary != nil && cachedBinary.LastEtag == bundleEtag {
+		err = writeReport(w, r, b.reportStore, "", cachedBinary.Report, b.convertOptions.Strict)
+		if err != nil {
+			return err
+		}
+		return b.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+	}
+	releaseConversion, acquired := acquireConversion(w, r)
+	if !acquired {
+		return nil
+	}
+	defer releaseConversion()
+	startedAt := time.Now()
+	convertOptions.Report = &adapter.ConvertReport{}
+	categories := make([]adapter.RuleCategory, 0, len(b.categories))
+	for index, category := range b.categories {
+		rules, err := category.endpoint.Rules(b.ctx, sourceBinaries[index], category.urlParams, convertOptions.Metadata, convertOptions.Report)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return E.Cause(err, "category ", category.name)
+		}
+		if b.convertOptions.Optimize {
+			rules, err = convertor.Optimize(rules)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return E.Cause(err, "optimize category ", category.name)
+			}
+		}
+		categories = append(categories, adapter.RuleCategory{
+			Name:  category.name,
+			Rules: rules,
+		})
+	}
+	binary, err := b.targetConvertor.ToBundle(b.ctx, categories, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "encode target")
+	}
+	observeConversion(b.metrics, b.convertOptions.TargetType, startedAt, convertOptions.Report)
+	if convertOptions.Report.Dropped() > 0 {
+		b.logger.Warn("dropped ", convertOptions.Report.Dropped(), " items converting ", r.URL.Path, ": ", convertOptions.Report.String())
+	} else {
+		convertOptions.Report = nil
+	}
+	cachedBinary = &adapter.SavedBinary{
+		Content:      binary,
+		LastUpdated:  lastUpdated,
+		LastEtag:     bundleEtag,
+		Report:       convertOptions.Report,
+		SourceDigest: bundleDigest,
+	}
+	err = b.cache.SaveBinary(cacheKey, cachedBinary)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "save cache binary")
+	}
+	err = writeReport(w, r, b.reportStore, "", cachedBinary.Report, b.convertOptions.Strict)
+	if err != nil {
+		return err
+	}
+	return b.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+}
+
+func (b *BundleEndpoint) writeCache(w http.ResponseWriter, r *http.Request, cacheKey string, cachedBinary *adapter.SavedBinary, convertOptions adapter.ConvertOptions) error {
+	return writeContent(w, r, b.cache, cacheKey, cachedBinary, b.targetConvertor.ContentType(convertOptions))
+}
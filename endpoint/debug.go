@@ -0,0 +1,48 @@
+package endpoint
+
+import (
+	"net/http"
+
+	"github.com/sagernet/sing/common/cache"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/srsc/adapter"
+)
+
+var (
+	_ http.Handler               = (*DebugEndpoint)(nil)
+	_ adapter.ConvertReportStore = (*DebugEndpoint)(nil)
+)
+
+// maxStoredReports is the number of reports kept by the debug endpoint,
+// since paths of templated endpoints are requested by clients and unbounded.
+const maxStoredReports = 256
+
+// DebugEndpoint serves the latest conversion reports of recently requested paths that dropped anything.
+type DebugEndpoint struct {
+	reports *cache.LruCache[string, *adapter.ConvertReport]
+}
+
+func NewDebugEndpoint() *DebugEndpoint {
+	return &DebugEndpoint{
+		reports: cache.New[string, *adapter.ConvertReport](cache.WithSize[string, *adapter.ConvertReport](maxStoredReports)),
+	}
+}
+
+func (d *DebugEndpoint) StoreReport(path string, report *adapter.ConvertReport) {
+	if report.Dropped() == 0 {
+		d.reports.Delete(path)
+	} else {
+		d.reports.Store(path, report)
+	}
+}
+
+func (d *DebugEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reports := make(map[string][]adapter.ConvertReportItem)
+	d.reports.Range(func(path string, report *adapter.ConvertReport) {
+		reports[path] = report.Items()
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(reports)
+}
@@ -0,0 +1,206 @@
+package endpoint
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/source"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+
+	// minCompressSize is the content size below which compression is not worth it.
+	minCompressSize = 512
+)
+
+// supportedEncodings lists supported content encodings in the order of preference.
+var supportedEncodings = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+// writeContent writes the content in the encoding negotiated by Accept-Encoding.
+// Encoded variants are stored in the cache next to the content, under the cache key with the encoding suffix,
+// so that the content is encoded once per update.
+func writeContent(w http.ResponseWriter, r *http.Request, cache adapter.Cache, cacheKey string, cachedBinary *adapter.SavedBinary, contentType string) error {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	content := cachedBinary.Content
+	etag := cachedBinary.LastEtag
+	if len(content) >= minCompressSize && isCompressible(contentType) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding != "" {
+			encodedBinary, err := loadEncoded(cache, cacheKey, cachedBinary, encoding)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return err
+			}
+			w.Header().Set("Content-Encoding", encoding)
+			content = encodedBinary.Content
+			etag = encodedBinary.LastEtag
+		}
+	}
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+		if matchETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+	w.Header().Set("Content-Length", F.ToString(len(content)))
+	_, err := w.Write(content)
+	if err != nil {
+		return E.Cause(err, "write cached content")
+	}
+	return nil
+}
+
+// loadEncoded loads the encoded variant of the content from the cache, or encodes and stores it if outdated.
+func loadEncoded(cache adapter.Cache, cacheKey string, cachedBinary *adapter.SavedBinary, encoding string) (*adapter.SavedBinary, error) {
+	encodedKey := cacheKey + "#" + encoding
+	encodedEtag := encodedETag(cachedBinary.LastEtag, encoding)
+	sourceDigest := cachedBinary.SourceDigest
+	if sourceDigest == "" {
+		sourceDigest = source.Digest(cachedBinary.Content)
+	}
+	encodedBinary, err := cache.LoadBinary(encodedKey)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, E.Cause(err, "load cache binary")
+	}
+	if encodedBinary != nil && encodedBinary.LastEtag == encodedEtag && encodedBinary.SourceDigest == sourceDigest {
+		return encodedBinary, nil
+	}
+	content, err := encodeContent(cachedBinary.Content, encoding)
+	if err != nil {
+		return nil, E.Cause(err, "encode ", encoding)
+	}
+	encodedBinary = &adapter.SavedBinary{
+		Content:      content,
+		LastUpdated:  cachedBinary.LastUpdated,
+		LastEtag:     encodedEtag,
+		SourceDigest: sourceDigest,
+	}
+	err = cache.SaveBinary(encodedKey, encodedBinary)
+	if err != nil {
+		return nil, E.Cause(err, "save cache binary")
+	}
+	return encodedBinary, nil
+}
+
+func encodeContent(content []byte, encoding string) ([]byte, error) {
+	var (
+		buffer bytes.Buffer
+		writer io.WriteCloser
+		err    error
+	)
+	switch encoding {
+	case encodingBrotli:
+		writer = brotli.NewWriterLevel(&buffer, 9)
+	case encodingZstd:
+		writer, err = zstd.NewWriter(&buffer, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	case encodingGzip:
+		writer, err = gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	default:
+		return nil, E.New("unknown encoding: ", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = writer.Write(content)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodedETag returns the ETag of the encoded variant, since variants of the same content must not share ETags.
+func encodedETag(etag string, encoding string) string {
+	if etag == "" {
+		return ""
+	}
+	if strings.HasSuffix(etag, "\"") {
+		return etag[:len(etag)-1] + "-" + encoding + "\""
+	}
+	return etag + "-" + encoding
+}
+
+// matchETag returns whether If-None-Match matches the ETag, using the weak comparison.
+func matchETag(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, item := range strings.Split(ifNoneMatch, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.TrimPrefix(item, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the supported encoding of the highest quality in Accept-Encoding, or empty for identity.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			var err error
+			quality, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+	var (
+		bestEncoding string
+		bestQuality  float64
+	)
+	for _, encoding := range supportedEncodings {
+		quality, loaded := qualities[encoding]
+		if !loaded {
+			quality, loaded = qualities["*"]
+		}
+		if loaded && quality > bestQuality {
+			bestEncoding = encoding
+			bestQuality = quality
+		}
+	}
+	return bestEncoding
+}
+
+// isCompressible returns whether the content type is text, binary targets are already compact or compressed.
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/json", "application/x-yaml":
+		return true
+	default:
+		return strings.HasPrefix(mediaType, "text/")
+	}
+}
@@ -0,0 +1,48 @@
+package endpoint
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "", negotiateEncoding(""))
+	require.Equal(t, "", negotiateEncoding("identity, deflate"))
+	require.Equal(t, encodingBrotli, negotiateEncoding("gzip, deflate, br, zstd"))
+	require.Equal(t, encodingGzip, negotiateEncoding("gzip;q=1.0, br;q=0.5"))
+	require.Equal(t, encodingZstd, negotiateEncoding("br;q=0, *"))
+	require.Equal(t, `"abc-gzip"`, encodedETag(`"abc"`, encodingGzip))
+}
+
+func TestEncodeContent(t *testing.T) {
+	t.Parallel()
+	content := bytes.Repeat([]byte("DOMAIN-SUFFIX,example.com\n"), 100)
+	for _, encoding := range supportedEncodings {
+		encoded, err := encodeContent(content, encoding)
+		require.NoError(t, err)
+		require.Less(t, len(encoded), len(content))
+		var reader io.Reader
+		switch encoding {
+		case encodingBrotli:
+			reader = brotli.NewReader(bytes.NewReader(encoded))
+		case encodingZstd:
+			decoder, err := zstd.NewReader(bytes.NewReader(encoded))
+			require.NoError(t, err)
+			defer decoder.Close()
+			reader = decoder
+		case encodingGzip:
+			reader, err = gzip.NewReader(bytes.NewReader(encoded))
+			require.NoError(t, err)
+		}
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, content, decoded)
+	}
+}
@@ -2,9 +2,14 @@ package endpoint
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/cache"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/logger"
@@ -14,34 +19,56 @@ import (
 	"github.com/sagernet/srsc/convertor"
 	"github.com/sagernet/srsc/option"
 	"github.com/sagernet/srsc/source"
+	"github.com/sagernet/srsc/transform"
 
 	"github.com/go-chi/chi/v5"
+	"golang.org/x/sync/singleflight"
 )
 
 var _ http.Handler = (*FileEndpoint)(nil)
 
+// maxCachedSources is the number of decoded sources and dependencies of sources kept by each file endpoint.
+const maxCachedSources = 64
+
 type FileEndpoint struct {
 	ctx             context.Context
 	logger          logger.ContextLogger
 	cache           adapter.Cache
 	resources       adapter.ResourceManager
+	reportStore     adapter.ConvertReportStore
+	metrics         adapter.Metrics
 	index           int
 	source          adapter.Source
 	sourceConvertor adapter.Convertor
-	targetConvertor adapter.Convertor
+	convertOptions  option.ConvertOptions
+	targets         []*fileTarget
+	transforms      []option.RuleTransform
+	transform       adapter.Transform
+	rulesCache      *cache.LruCache[string, *decodedRules]
+	rulesGroup      singleflight.Group
+	dependencies    *cache.LruCache[string, []source.Dependency]
+}
+
+type fileTarget struct {
+	name            string
+	convertor       adapter.Convertor
 	convertOptions  option.ConvertOptions
 	convertRequired bool
 }
 
 func NewFileEndpoint(ctx context.Context, logger logger.ContextLogger, index int, options option.FileEndpoint) (*FileEndpoint, error) {
 	ep := &FileEndpoint{
-		ctx:             ctx,
-		logger:          logger,
-		cache:           service.FromContext[adapter.Cache](ctx),
-		resources:       service.FromContext[adapter.ResourceManager](ctx),
-		index:           index,
-		convertOptions:  options.ConvertOptions,
-		convertRequired: options.ConvertOptions.ConvertRequired(),
+		ctx:            ctx,
+		logger:         logger,
+		cache:          service.FromContext[adapter.Cache](ctx),
+		resources:      service.FromContext[adapter.ResourceManager](ctx),
+		reportStore:    service.FromContext[adapter.ConvertReportStore](ctx),
+		metrics:        service.FromContext[adapter.Metrics](ctx),
+		index:          index,
+		convertOptions: options.ConvertOptions,
+		transforms:     options.Transforms,
+		rulesCache:     cache.New[string, *decodedRules](cache.WithSize[string, *decodedRules](maxCachedSources)),
+		dependencies:   cache.New[string, []source.Dependency](cache.WithSize[string, []source.Dependency](maxCachedSources)),
 	}
 	endpointSource, err := source.New(ctx, options.SourceOptions)
 	if err != nil {
@@ -53,14 +80,85 @@ func NewFileEndpoint(ctx context.Context, logger logger.ContextLogger, index int
 		return nil, E.New("unknown source type: ", options.SourceType)
 	}
 	ep.sourceConvertor = sourceConvertor
-	targetConvertor, loaded := convertor.Convertors[options.TargetType]
-	if !loaded {
-		return nil, E.New("unknown target type: ", options.TargetType)
+	if len(options.Targets) == 0 {
+		target, err := newFileTarget("", options.SourceConvertOptions, options.TargetConvertOptions, len(options.Transforms) > 0)
+		if err != nil {
+			return nil, err
+		}
+		ep.targets = []*fileTarget{target}
+		return ep, nil
+	}
+	for i, targetOptions := range options.Targets {
+		name := targetOptions.Name
+		if name == "" {
+			name = targetOptions.TargetOptions.TargetType
+		}
+		if common.Any(ep.targets, func(it *fileTarget) bool {
+			return it.name == name
+		}) {
+			return nil, E.New("targets[", i, "]: duplicate target name: ", name)
+		}
+		target, err := newFileTarget(name, options.SourceConvertOptions, targetOptions.TargetOptions, len(options.Transforms) > 0)
+		if err != nil {
+			return nil, E.Cause(err, "targets[", i, "]")
+		}
+		ep.targets = append(ep.targets, target)
 	}
-	ep.targetConvertor = targetConvertor
 	return ep, nil
 }
 
+func newFileTarget(name string, sourceOptions option.SourceConvertOptions, targetOptions option.TargetConvertOptions, hasTransforms bool) (*fileTarget, error) {
+	targetConvertor, loaded := convertor.Convertors[targetOptions.TargetType]
+	if !loaded {
+		return nil, E.New("unknown target type: ", targetOptions.TargetType)
+	}
+	err := checkTargetVersion(targetOptions)
+	if err != nil {
+		return nil, err
+	}
+	convertOptions := option.ConvertOptions{
+		SourceConvertOptions: sourceOptions,
+		TargetConvertOptions: targetOptions,
+	}
+	return &fileTarget{
+		name:            name,
+		convertor:       targetConvertor,
+		convertOptions:  convertOptions,
+		convertRequired: convertOptions.ConvertRequired() || hasTransforms,
+	}, nil
+}
+
+// InitializeTransforms creates transforms of the endpoint,
+// which is called after all file endpoints are registered in the router, since transforms reference file endpoints by path.
+func (f *FileEndpoint) InitializeTransforms(router *chi.Mux, fileEndpoints map[string]*FileEndpoint) error {
+	if len(f.transforms) == 0 {
+		return nil
+	}
+	pipeline, err := transform.NewPipeline(f.ctx, f.transforms, func(path string) (transform.RuleLoader, error) {
+		fileEndpoint, urlParams, err := findFileEndpoint(router, fileEndpoints, path)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, metadata C.Metadata) ([]adapter.Rule, error) {
+			cachePath, err := fileEndpoint.source.Path(urlParams)
+			if err != nil {
+				return nil, E.Cause(err, "evaluate source path")
+			}
+			// the referenced source is a dependency of the source of this endpoint.
+			sourceBinary, err := source.FetchDependency(ctx, fileEndpoint.cache, fileEndpoint.source, cachePath)
+			if err != nil {
+				return nil, err
+			}
+			return fileEndpoint.Rules(ctx, sourceBinary, urlParams, metadata, nil)
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	f.transform = pipeline
+	return nil
+}
+
 func (f *FileEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	err := f.serveHTTP0(w, r)
 	if err != nil {
@@ -71,10 +169,6 @@ func (f *FileEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (f *FileEndpoint) serveHTTP0(w http.ResponseWriter, r *http.Request) error {
-	convertOptions := adapter.ConvertOptions{
-		Options:  f.convertOptions,
-		Metadata: C.DetectMetadata(r.UserAgent()),
-	}
 	var urlParams map[string]string // TODO: improve performance
 	rawURLParams := chi.RouteContext(r.Context()).URLParams
 	if len(rawURLParams.Keys) > 0 {
@@ -83,87 +177,342 @@ func (f *FileEndpoint) serveHTTP0(w http.ResponseWriter, r *http.Request) error
 			urlParams[key] = rawURLParams.Values[i]
 		}
 	}
+	metadata, err := detectMetadata(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	target, err := f.selectTarget(r, urlParams, metadata)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return err
+	}
+	convertOptions := adapter.ConvertOptions{
+		Options:  target.convertOptions,
+		Metadata: metadata,
+		Params:   urlParams,
+	}
 	cachePath, err := f.source.Path(urlParams)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return E.Cause(err, "evaluate source path")
 	}
-	cacheKey := F.ToString("file.", f.index, ".", cachePath)
+	sourceBinary, err := source.Fetch(f.cache, f.source, cachePath)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+	cachePrefix := cacheKeyPrefix(f.ctx, C.EndpointTypeFile, f.index) + "."
+	if target.name != "" {
+		cachePrefix += target.name + "."
+	}
+	cacheKey := source.CacheKey(cachePrefix, cachePath, urlParams)
+	sourceDigest, err := f.SourceDigest(sourceBinary, urlParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+	if !target.convertRequired {
+		servedBinary := *sourceBinary
+		servedBinary.LastEtag = f.etag(cacheKey, sourceDigest)
+		return f.writeCache(w, r, cacheKey, &servedBinary, target, convertOptions)
+	}
+	cacheKey, err = versionedCacheKey(cacheKey, target.convertor, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
 	cachedBinary, err := f.cache.LoadBinary(cacheKey)
 	if err != nil && !os.IsNotExist(err) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return E.Cause(err, "load cache binary")
 	}
-	lastUpdated := f.source.LastUpdated(cachePath)
-	if cachedBinary != nil && !lastUpdated.IsZero() && cachedBinary.LastUpdated.Equal(lastUpdated) {
-		return f.writeCache(w, cachedBinary, convertOptions)
-	}
-
-	var fetchBody adapter.FetchRequestBody
-	if cachedBinary != nil {
-		fetchBody.ETag = cachedBinary.LastEtag
-		fetchBody.LastUpdated = cachedBinary.LastUpdated
+	if cachedBinary != nil && cachedBinary.SourceDigest == sourceDigest && cachedBinary.LastEtag == f.etag(cacheKey, sourceDigest) {
+		err = writeReport(w, r, f.reportStore, target.name, cachedBinary.Report, target.convertOptions.Strict)
+		if err != nil {
+			return err
+		}
+		return f.writeCache(w, r, cacheKey, cachedBinary, target, convertOptions)
 	}
-	response, err := f.source.Fetch(cachePath, fetchBody)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		return E.Cause(err, "fetch source")
+	releaseConversion, acquired := acquireConversion(w, r)
+	if !acquired {
+		return nil
 	}
-	if response.NotModified {
-		if cachedBinary == nil {
-			w.WriteHeader(http.StatusBadGateway)
-			return E.New("fetch source: unexpected not modified response")
+	defer releaseConversion()
+	startedAt := time.Now()
+	convertOptions.Report = &adapter.ConvertReport{}
+	var rules []adapter.Rule
+	if len(f.targets) > 1 {
+		var decoded *decodedRules
+		decoded, err = f.loadRules(f.ctx, sourceBinary, sourceDigest, urlParams, metadata)
+		if err == nil {
+			convertOptions.Report.Merge(decoded.report)
+			rules = adapter.CloneRules(decoded.rules)
+			sourceDigest = decoded.sourceDigest
 		}
-		if response.LastUpdated != cachedBinary.LastUpdated {
-			cachedBinary.LastUpdated = response.LastUpdated
-			err = f.cache.SaveBinary(cacheKey, cachedBinary)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return E.Cause(err, "save cache binary")
-			}
-		}
-		return f.writeCache(w, cachedBinary, convertOptions)
+	} else {
+		rules, sourceDigest, err = f.rules(f.ctx, sourceBinary, urlParams, metadata, convertOptions.Report)
 	}
-	if len(response.Content) == 0 {
-		w.WriteHeader(http.StatusBadGateway)
-		return E.Cause(err, "fetch source: empty content")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
 	}
-	binary := response.Content
-	if f.convertRequired {
-		var rules []adapter.Rule
-		rules, err = f.sourceConvertor.From(f.ctx, response.Content, convertOptions)
+	if target.convertOptions.Optimize {
+		rules, err = convertor.Optimize(rules)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
-			return E.Cause(err, "decode source")
-		}
-		binary, err = f.targetConvertor.To(f.ctx, rules, convertOptions)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			return E.Cause(err, "encode target")
+			return E.Cause(err, "optimize rules")
 		}
 	}
+	binary, err := target.convertor.To(f.ctx, rules, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "encode target")
+	}
+	observeConversion(f.metrics, target.convertOptions.TargetType, startedAt, convertOptions.Report)
+	if convertOptions.Report.Dropped() > 0 {
+		f.logger.Warn("dropped ", convertOptions.Report.Dropped(), " items converting ", r.URL.Path, ": ", convertOptions.Report.String())
+	} else {
+		convertOptions.Report = nil
+	}
 	cachedBinary = &adapter.SavedBinary{
-		Content:     binary,
-		LastUpdated: response.LastUpdated,
-		LastEtag:    response.ETag,
+		Content:      binary,
+		LastUpdated:  sourceBinary.LastUpdated,
+		LastEtag:     f.etag(cacheKey, sourceDigest),
+		Report:       convertOptions.Report,
+		SourceDigest: sourceDigest,
 	}
 	err = f.cache.SaveBinary(cacheKey, cachedBinary)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return E.Cause(err, "save cache binary")
 	}
-	return f.writeCache(w, cachedBinary, convertOptions)
+	err = writeReport(w, r, f.reportStore, target.name, cachedBinary.Report, target.convertOptions.Strict)
+	if err != nil {
+		return err
+	}
+	return f.writeCache(w, r, cacheKey, cachedBinary, target, convertOptions)
+}
+
+// FetchSource fetches the source content of the endpoint for the URL parameters.
+func (f *FileEndpoint) FetchSource(urlParams map[string]string) (*adapter.SavedBinary, error) {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return nil, E.Cause(err, "evaluate source path")
+	}
+	return source.Fetch(f.cache, f.source, cachePath)
 }
 
-func (f *FileEndpoint) writeCache(w http.ResponseWriter, cachedBinary *adapter.SavedBinary, convertOptions adapter.ConvertOptions) error {
-	w.Header().Set("Content-Type", f.targetConvertor.ContentType(convertOptions)+"; charset=utf-8")
-	w.Header().Set("Content-Length", F.ToString(len(cachedBinary.Content)))
-	if cachedBinary.LastEtag != "" {
-		w.Header().Set("ETag", cachedBinary.LastEtag)
+// CheckSource returns an error if the source of the endpoint for the URL parameters is not cached,
+// or a remote source has not been checked for updates within twice its TTL, the source is not fetched.
+func (f *FileEndpoint) CheckSource(urlParams map[string]string) error {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return E.Cause(err, "evaluate source path")
 	}
-	_, err := w.Write(cachedBinary.Content)
+	sourceBinary, err := f.cache.LoadBinary("source." + cachePath)
 	if err != nil {
-		return E.Cause(err, "write cached content")
+		return E.Cause(err, "load cache binary")
+	}
+	if sourceBinary == nil {
+		return E.New("source not loaded")
+	}
+	if remote, isRemote := f.source.(*source.Remote); isRemote && time.Since(sourceBinary.LastUpdated) > 2*remote.TTL() {
+		return E.New("source outdated, last updated at ", sourceBinary.LastUpdated.Format(time.RFC3339))
 	}
 	return nil
 }
+
+// RefreshSources fetches the source of the endpoint for the URL parameters ignoring the cache,
+// contents converted from the previous source are outdated and converted again when requested.
+func (f *FileEndpoint) RefreshSources(urlParams map[string]string) ([]string, error) {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return nil, E.Cause(err, "evaluate source path")
+	}
+	_, err = source.Refresh(f.cache, f.source, cachePath)
+	if err != nil {
+		return nil, err
+	}
+	return []string{cachePath}, nil
+}
+
+// SourceDigest returns the digest of the source content fetched by FetchSource and current contents of its dependencies,
+// which identifies contents converted from the source.
+func (f *FileEndpoint) SourceDigest(sourceBinary *adapter.SavedBinary, urlParams map[string]string) (string, error) {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return "", E.Cause(err, "evaluate source path")
+	}
+	dependencies, _ := f.dependencies.Load(source.CacheKey("", cachePath, urlParams))
+	dependencyBinaries, err := source.FetchDependencies(f.cache, dependencies)
+	if err != nil {
+		return "", E.Cause(err, "fetch dependency")
+	}
+	return source.DerivedDigest(append([]*adapter.SavedBinary{sourceBinary}, dependencyBinaries...)...), nil
+}
+
+// DecodeSource decodes the source content fetched by FetchSource into rules.
+func (f *FileEndpoint) DecodeSource(ctx context.Context, sourceBinary *adapter.SavedBinary, urlParams map[string]string, metadata C.Metadata, report *adapter.ConvertReport) ([]adapter.Rule, error) {
+	rules, err := f.sourceConvertor.From(ctx, sourceBinary.Content, adapter.ConvertOptions{
+		Options:  f.convertOptions,
+		Metadata: metadata,
+		Params:   urlParams,
+		Report:   report,
+	})
+	if err != nil {
+		return nil, E.Cause(err, "decode source")
+	}
+	return rules, nil
+}
+
+// Rules decodes the source content fetched by FetchSource into rules and applies transforms of the endpoint.
+// Sources fetched by decoding, such as referenced rule-sets, are recorded as dependencies of the source.
+func (f *FileEndpoint) Rules(ctx context.Context, sourceBinary *adapter.SavedBinary, urlParams map[string]string, metadata C.Metadata, report *adapter.ConvertReport) ([]adapter.Rule, error) {
+	rules, _, err := f.rules(ctx, sourceBinary, urlParams, metadata, report)
+	return rules, err
+}
+
+// rules is Rules returning the source digest of the decoded contents with its dependencies.
+func (f *FileEndpoint) rules(ctx context.Context, sourceBinary *adapter.SavedBinary, urlParams map[string]string, metadata C.Metadata, report *adapter.ConvertReport) ([]adapter.Rule, string, error) {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return nil, "", E.Cause(err, "evaluate source path")
+	}
+	ctx, err = withRulesChain(ctx, f, cachePath)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, recordedDependencies := source.RecordDependencies(ctx)
+	rules, err := f.decodeAndTransform(ctx, sourceBinary, urlParams, metadata, report)
+	if err != nil {
+		return nil, "", err
+	}
+	dependencies, dependencyBinaries := recordedDependencies()
+	if len(dependencies) > 0 {
+		f.dependencies.Store(source.CacheKey("", cachePath, urlParams), dependencies)
+	} else {
+		f.dependencies.Delete(source.CacheKey("", cachePath, urlParams))
+	}
+	return rules, source.DerivedDigest(append([]*adapter.SavedBinary{sourceBinary}, dependencyBinaries...)...), nil
+}
+
+type rulesChain struct {
+	parent    *rulesChain
+	endpoint  *FileEndpoint
+	cachePath string
+}
+
+type rulesChainKey struct{}
+
+// withRulesChain records the source being decoded to the context,
+// since transforms may load rules of other endpoints, which may reference the source again.
+func withRulesChain(ctx context.Context, endpoint *FileEndpoint, cachePath string) (context.Context, error) {
+	chain, _ := ctx.Value(rulesChainKey{}).(*rulesChain)
+	for current := chain; current != nil; current = current.parent {
+		if current.endpoint == endpoint && current.cachePath == cachePath {
+			return nil, E.New("reference cycle detected")
+		}
+	}
+	return context.WithValue(ctx, rulesChainKey{}, &rulesChain{
+		parent:    chain,
+		endpoint:  endpoint,
+		cachePath: cachePath,
+	}), nil
+}
+
+func (f *FileEndpoint) decodeAndTransform(ctx context.Context, sourceBinary *adapter.SavedBinary, urlParams map[string]string, metadata C.Metadata, report *adapter.ConvertReport) ([]adapter.Rule, error) {
+	rules, err := f.DecodeSource(ctx, sourceBinary, urlParams, metadata, report)
+	if err != nil {
+		return nil, err
+	}
+	if f.transform != nil {
+		rules, err = f.transform.Apply(ctx, rules, adapter.ConvertOptions{
+			Options:  f.convertOptions,
+			Metadata: metadata,
+			Params:   urlParams,
+			Report:   report,
+		})
+		if err != nil {
+			return nil, E.Cause(err, "transform rules")
+		}
+	}
+	return rules, nil
+}
+
+// decodedRules is the rules of a source update of the endpoint, shared by conversions to multiple targets.
+type decodedRules struct {
+	sourceDigest string
+	rules        []adapter.Rule
+	report       *adapter.ConvertReport
+}
+
+// loadRules returns rules of the source content like Rules, decoded once per source update.
+// The rules are shared and must be cloned before being modified.
+// The source digest is from SourceDigest, and the one of the decoded contents is returned with the rules.
+func (f *FileEndpoint) loadRules(ctx context.Context, sourceBinary *adapter.SavedBinary, sourceDigest string, urlParams map[string]string, metadata C.Metadata) (*decodedRules, error) {
+	cachePath, err := f.source.Path(urlParams)
+	if err != nil {
+		return nil, E.Cause(err, "evaluate source path")
+	}
+	cacheKey := source.CacheKey("", cachePath, urlParams)
+	decoded, loaded := f.rulesCache.Load(cacheKey)
+	if loaded && decoded.sourceDigest == sourceDigest {
+		return decoded, nil
+	}
+	// concurrent requests of the same source update are decoded once.
+	result, err, _ := f.rulesGroup.Do(cacheKey+"\n"+sourceDigest, func() (any, error) {
+		report := &adapter.ConvertReport{}
+		rules, decodedDigest, err := f.rules(ctx, sourceBinary, urlParams, metadata, report)
+		if err != nil {
+			return nil, err
+		}
+		decoded := &decodedRules{
+			sourceDigest: decodedDigest,
+			rules:        rules,
+			report:       report,
+		}
+		f.rulesCache.Store(cacheKey, decoded)
+		return decoded, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*decodedRules), nil
+}
+
+// findFileEndpoint returns the file endpoint matching the path and URL parameters of the path.
+func findFileEndpoint(router *chi.Mux, fileEndpoints map[string]*FileEndpoint, path string) (*FileEndpoint, map[string]string, error) {
+	routeContext := chi.NewRouteContext()
+	pattern := router.Find(routeContext, http.MethodGet, path)
+	if pattern == "" {
+		return nil, nil, E.New("endpoint not found: ", path)
+	}
+	fileEndpoint, loaded := fileEndpoints[pattern]
+	if !loaded {
+		return nil, nil, E.New("not a file endpoint: ", pattern)
+	}
+	var urlParams map[string]string
+	if len(routeContext.URLParams.Keys) > 0 {
+		urlParams = make(map[string]string)
+		for i, key := range routeContext.URLParams.Keys {
+			urlParams[key] = routeContext.URLParams.Values[i]
+		}
+	}
+	return fileEndpoint, urlParams, nil
+}
+
+// etag returns the ETag of the content under the cache key, which includes the target and the version variant,
+// so that targets served on the same path do not share ETags.
+func (f *FileEndpoint) etag(cacheKey string, sourceDigest string) string {
+	hash := sha256.New()
+	hash.Write([]byte(F.ToString(cacheKey, "\n", sourceDigest, "\n")))
+	return "\"" + hex.EncodeToString(hash.Sum(nil)[:16]) + "\""
+}
+
+func (f *FileEndpoint) writeCache(w http.ResponseWriter, r *http.Request, cacheKey string, cachedBinary *adapter.SavedBinary, target *fileTarget, convertOptions adapter.ConvertOptions) error {
+	if len(f.targets) > 1 {
+		w.Header().Set("Vary", "Accept, User-Agent")
+	}
+	return writeContent(w, r, f.cache, cacheKey, cachedBinary, target.convertor.ContentType(convertOptions))
+}
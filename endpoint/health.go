@@ -0,0 +1,164 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+	"github.com/sagernet/srsc/source"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// readinessPingTimeout bounds pinging the cache in readiness probes.
+const readinessPingTimeout = 5 * time.Second
+
+// HealthEndpoint serves liveness and readiness probes.
+type HealthEndpoint struct {
+	ctx       context.Context
+	logger    logger.ContextLogger
+	cache     adapter.Cache
+	resources adapter.ResourceManager
+	endpoints []healthEndpoint
+	cancel    context.CancelFunc
+}
+
+type healthEndpoint struct {
+	path      string
+	endpoint  *FileEndpoint
+	urlParams map[string]string
+}
+
+// NewHealthEndpoint creates probes checking critical file endpoints,
+// which are resolved by matching their paths against the file endpoints registered in the router.
+func NewHealthEndpoint(ctx context.Context, logger logger.ContextLogger, options option.HealthOptions, router *chi.Mux, fileEndpoints map[string]*FileEndpoint) (*HealthEndpoint, error) {
+	h := &HealthEndpoint{
+		ctx:       ctx,
+		logger:    logger,
+		cache:     service.FromContext[adapter.Cache](ctx),
+		resources: service.FromContext[adapter.ResourceManager](ctx),
+	}
+	for i, path := range options.Endpoints {
+		fileEndpoint, urlParams, err := findFileEndpoint(router, fileEndpoints, path)
+		if err != nil {
+			return nil, E.Cause(err, "endpoints[", i, "]")
+		}
+		h.endpoints = append(h.endpoints, healthEndpoint{
+			path:      path,
+			endpoint:  fileEndpoint,
+			urlParams: urlParams,
+		})
+	}
+	return h, nil
+}
+
+// Start loads resources and sources of critical endpoints in background, and refreshes sources every half of their TTL,
+// so that readiness does not depend on requests.
+func (h *HealthEndpoint) Start() {
+	if h.resources == nil && len(h.endpoints) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.cancel = cancel
+	go h.loopRefresh(ctx)
+}
+
+func (h *HealthEndpoint) Close() error {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	return nil
+}
+
+func (h *HealthEndpoint) loopRefresh(ctx context.Context) {
+	ticker := time.NewTicker(h.refreshInterval())
+	defer ticker.Stop()
+	for {
+		h.refresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshInterval returns half of the shortest TTL of remote sources of critical endpoints.
+func (h *HealthEndpoint) refreshInterval() time.Duration {
+	ttl := C.DefaultTTL
+	for _, critical := range h.endpoints {
+		if remote, isRemote := critical.endpoint.source.(*source.Remote); isRemote && remote.TTL() < ttl {
+			ttl = remote.TTL()
+		}
+	}
+	return ttl / 2
+}
+
+func (h *HealthEndpoint) refresh() {
+	if h.resources != nil && h.resources.CheckLoaded() != nil {
+		err := h.resources.Preload()
+		if err != nil {
+			h.logger.Warn(E.Cause(err, "preload resources"))
+		}
+	}
+	for _, critical := range h.endpoints {
+		_, err := critical.endpoint.FetchSource(critical.urlParams)
+		if err != nil {
+			h.logger.Warn(E.Cause(err, "refresh endpoint ", critical.path))
+		}
+	}
+}
+
+// ServeHealthz responds if the process is alive.
+func (h *HealthEndpoint) ServeHealthz(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, nil)
+}
+
+// ServeReadyz responds if the cache is reachable, resources have been loaded at least once,
+// and sources of critical endpoints are cached and fresh, nothing is fetched by the probe.
+func (h *HealthEndpoint) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	var errors []error
+	ctx, cancel := context.WithTimeout(r.Context(), readinessPingTimeout)
+	err := h.cache.Ping(ctx)
+	cancel()
+	if err != nil {
+		errors = append(errors, E.Cause(err, "cache"))
+	}
+	if h.resources != nil {
+		err = h.resources.CheckLoaded()
+		if err != nil {
+			errors = append(errors, E.Cause(err, "resources"))
+		}
+	}
+	for _, critical := range h.endpoints {
+		err = critical.endpoint.CheckSource(critical.urlParams)
+		if err != nil {
+			errors = append(errors, E.Cause(err, "endpoint ", critical.path))
+		}
+	}
+	writeProbe(w, errors)
+}
+
+func writeProbe(w http.ResponseWriter, errors []error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if len(errors) == 0 {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	var builder strings.Builder
+	for _, err := range errors {
+		builder.WriteString(err.Error())
+		builder.WriteString("\n")
+	}
+	_, _ = w.Write([]byte(builder.String()))
+}
@@ -0,0 +1,89 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/cache"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthEndpoint(t *testing.T) {
+	t.Parallel()
+	ctx := service.ContextWithDefaultRegistry(context.Background())
+	memoryCache := cache.NewMemory(0)
+	service.MustRegister[adapter.Cache](ctx, memoryCache)
+	resources := &testResourceManager{loadErr: E.New("geoip resource not loaded")}
+	service.MustRegister[adapter.ResourceManager](ctx, resources)
+	sourcePath := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(`{"version": 3, "rules": []}`), 0o644))
+	localEndpoint, err := NewFileEndpoint(ctx, logger.NOP(), 0, parseEndpoint(t, `{
+  "type": "file",
+  "source": "local",
+  "path": "`+sourcePath+`",
+  "source_type": "source",
+  "target_type": "source"
+}`).FileOptions)
+	require.NoError(t, err)
+	remoteEndpoint, err := NewFileEndpoint(ctx, logger.NOP(), 1, parseEndpoint(t, `{
+  "type": "file",
+  "source": "remote",
+  "url": "http://127.0.0.1:1/rules.json",
+  "ttl": "1m",
+  "source_type": "source",
+  "target_type": "source"
+}`).FileOptions)
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	router.Get("/local.json", localEndpoint.ServeHTTP)
+	router.Get("/remote.json", remoteEndpoint.ServeHTTP)
+	health, err := NewHealthEndpoint(ctx, logger.NOP(), option.HealthOptions{
+		Endpoints: []string{"/local.json", "/remote.json"},
+	}, router, map[string]*FileEndpoint{
+		"/local.json":  localEndpoint,
+		"/remote.json": remoteEndpoint,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, health.refreshInterval())
+	require.Equal(t, http.StatusOK, serve(health.ServeHealthz, "/healthz").Code)
+
+	recorder := serve(health.ServeReadyz, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.Equal(t, "resources: geoip resource not loaded\n"+
+		"endpoint /local.json: source not loaded\n"+
+		"endpoint /remote.json: source not loaded\n", recorder.Body.String())
+
+	// the probe does not fetch sources.
+	recorder = serve(health.ServeReadyz, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	resources.loadErr = nil
+	health.refresh()
+	require.NoError(t, localEndpoint.CheckSource(nil))
+	require.NoError(t, memoryCache.SaveBinary("source.http://127.0.0.1:1/rules.json", &adapter.SavedBinary{
+		Content:     []byte(`{"version": 3, "rules": []}`),
+		LastUpdated: time.Now().Add(-3 * time.Minute),
+	}))
+	recorder = serve(health.ServeReadyz, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "endpoint /remote.json: source outdated")
+
+	require.NoError(t, memoryCache.SaveBinary("source.http://127.0.0.1:1/rules.json", &adapter.SavedBinary{
+		Content:     []byte(`{"version": 3, "rules": []}`),
+		LastUpdated: time.Now(),
+	}))
+	recorder = serve(health.ServeReadyz, "/readyz")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "ok\n", recorder.Body.String())
+}
@@ -0,0 +1,166 @@
+package endpoint
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter limits the request rate of each client with a token bucket,
+// and the number of conversions of endpoints running concurrently, requests served from the cache are not limited.
+type Limiter struct {
+	logger       logger.ContextLogger
+	interval     time.Duration
+	burst        int
+	byToken      bool
+	clientAccess sync.Mutex
+	clients      map[string]*clientLimiter
+	lastCleanup  time.Time
+	conversions  chan struct{}
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewLimiter(logger logger.ContextLogger, options option.LimitOptions) (*Limiter, error) {
+	limiter := &Limiter{
+		logger:   logger,
+		interval: time.Duration(options.Interval),
+		burst:    options.Burst,
+	}
+	switch options.By {
+	case "", C.LimitByIP:
+	case C.LimitByToken:
+		limiter.byToken = true
+	default:
+		return nil, E.New("unknown limit by: ", options.By)
+	}
+	if limiter.interval < 0 {
+		return nil, E.New("invalid interval: ", options.Interval)
+	}
+	if limiter.interval > 0 {
+		if limiter.burst == 0 {
+			limiter.burst = 1
+		} else if limiter.burst < 0 {
+			return nil, E.New("invalid burst: ", options.Burst)
+		}
+		limiter.clients = make(map[string]*clientLimiter)
+	} else if limiter.burst != 0 {
+		return nil, E.New("burst requires interval")
+	}
+	if options.MaxConcurrentConversions < 0 {
+		return nil, E.New("invalid max_concurrent_conversions: ", options.MaxConcurrentConversions)
+	}
+	if options.MaxConcurrentConversions > 0 {
+		limiter.conversions = make(chan struct{}, options.MaxConcurrentConversions)
+	}
+	return limiter, nil
+}
+
+// Handler returns the handler checking limits before calling next,
+// requests over limits are rejected with 429 and Retry-After.
+func (l *Limiter) Handler(next http.HandlerFunc) http.HandlerFunc {
+	if l.clients == nil && l.conversions == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l.clients != nil {
+			delay := l.reserve(l.clientKey(r))
+			if delay > 0 {
+				l.reject(w, r, delay, "rate limit exceeded")
+				return
+			}
+		}
+		if l.conversions != nil {
+			r = r.WithContext(context.WithValue(r.Context(), (*conversionLimitKey)(nil), l))
+		}
+		next(w, r)
+	}
+}
+
+type conversionLimitKey struct{}
+
+// acquireConversion takes a conversion slot of the limiter handling the request before converting,
+// the returned function releases the slot.
+// If no slot is available, the request is rejected with 429 and Retry-After, and false is returned.
+func acquireConversion(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	limiter, _ := r.Context().Value((*conversionLimitKey)(nil)).(*Limiter)
+	if limiter == nil {
+		return func() {}, true
+	}
+	select {
+	case limiter.conversions <- struct{}{}:
+		return func() {
+			<-limiter.conversions
+		}, true
+	default:
+		limiter.reject(w, r, time.Second, "too many concurrent conversions")
+		return nil, false
+	}
+}
+
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request, delay time.Duration, reason string) {
+	w.Header().Set("Retry-After", F.ToString(int64(math.Ceil(delay.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	l.logger.Warn("limited ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", reason)
+}
+
+// clientKey returns the token or user authenticated by access control if limited by token, or the source IP.
+func (l *Limiter) clientKey(r *http.Request) string {
+	if l.byToken {
+		identity := accessIdentity(r.Context())
+		if identity != "" {
+			return identity
+		}
+	}
+	return "ip." + M.ParseSocksaddr(r.RemoteAddr).Addr.Unmap().String()
+}
+
+// reserve takes a token from the bucket of the client, or returns the delay until a token is available.
+func (l *Limiter) reserve(key string) time.Duration {
+	now := time.Now()
+	l.clientAccess.Lock()
+	defer l.clientAccess.Unlock()
+	l.cleanup(now)
+	client := l.clients[key]
+	if client == nil {
+		client = &clientLimiter{
+			limiter: rate.NewLimiter(rate.Every(l.interval), l.burst),
+		}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	reservation := client.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// cleanup removes buckets of clients idle long enough for buckets to be full again, which are equal to new buckets.
+func (l *Limiter) cleanup(now time.Time) {
+	refillDuration := l.interval * time.Duration(l.burst)
+	if now.Sub(l.lastCleanup) < refillDuration {
+		return
+	}
+	l.lastCleanup = now
+	for key, client := range l.clients {
+		if now.Sub(client.lastSeen) >= refillDuration {
+			delete(l.clients, key)
+		}
+	}
+}
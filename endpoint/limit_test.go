@@ -0,0 +1,71 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+	limiter, err := NewLimiter(logger.NOP(), option.LimitOptions{
+		Interval: badoption.Duration(time.Hour),
+		Burst:    2,
+	})
+	require.NoError(t, err)
+	handler := limiter.Handler(func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/test", nil)
+		request.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+	require.Equal(t, http.StatusOK, serve("192.0.2.1:1234").Code)
+	require.Equal(t, http.StatusOK, serve("192.0.2.1:1235").Code)
+	recorder := serve("192.0.2.1:1236")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "3600", recorder.Header().Get("Retry-After"))
+	require.Equal(t, http.StatusOK, serve("192.0.2.2:1234").Code)
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	t.Parallel()
+	limiter, err := NewLimiter(logger.NOP(), option.LimitOptions{
+		MaxConcurrentConversions: 1,
+	})
+	require.NoError(t, err)
+	started := make(chan struct{})
+	done := make(chan struct{})
+	handler := limiter.Handler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cached" {
+			return
+		}
+		releaseConversion, acquired := acquireConversion(w, r)
+		if !acquired {
+			return
+		}
+		defer releaseConversion()
+		if r.URL.Path == "/block" {
+			close(started)
+			<-done
+		}
+	})
+	go handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/block", nil))
+	<-started
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/cached", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	close(done)
+}
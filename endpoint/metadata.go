@@ -0,0 +1,83 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+)
+
+// detectMetadata detects the client of the request from User-Agent,
+// the version query parameter overrides the sing-box version to generate rule-sets for.
+func detectMetadata(r *http.Request) (C.Metadata, error) {
+	metadata := C.DetectMetadata(r.UserAgent())
+	versionName := r.URL.Query().Get("version")
+	if versionName != "" {
+		version, err := convertor.ParseTargetVersion(versionName)
+		if err != nil {
+			return C.Metadata{}, E.Cause(err, "parse version query")
+		}
+		metadata.Platform = C.PlatformSingBox
+		metadata.Version = version
+	}
+	return metadata, nil
+}
+
+// checkTargetVersion validates target_version of sing-box rule-set targets.
+func checkTargetVersion(options option.TargetConvertOptions) error {
+	if options.RuleSetOptions.TargetVersion == "" {
+		return nil
+	}
+	_, err := convertor.ParseTargetVersion(options.RuleSetOptions.TargetVersion)
+	if err != nil {
+		return E.Cause(err, "parse target_version")
+	}
+	return nil
+}
+
+type optionsDigestKey struct{}
+
+// ContextWithOptionsDigest returns the context with the digest of options endpoints are created from,
+// so that contents converted with other options are not served from the cache kept across reloads.
+func ContextWithOptionsDigest(ctx context.Context, digest string) context.Context {
+	return context.WithValue(ctx, (*optionsDigestKey)(nil), digest)
+}
+
+// cacheKeyPrefix returns the prefix of cache keys of contents converted by the endpoint of the type and index.
+func cacheKeyPrefix(ctx context.Context, endpointType string, index int) string {
+	digest, _ := ctx.Value((*optionsDigestKey)(nil)).(string)
+	if digest == "" {
+		return F.ToString(endpointType, ".", index)
+	}
+	return F.ToString(endpointType, ".", index, ".", digest)
+}
+
+// versionedCacheKey returns the cache key of the converted content for the sing-box version rule-sets are generated for,
+// since sing-box rule-set targets generate different content for clients of older versions,
+// and Clash rule provider targets generate different content for Clash Premium clients.
+func versionedCacheKey(cacheKey string, targetConvertor adapter.Convertor, convertOptions adapter.ConvertOptions) (string, error) {
+	switch targetConvertor.Type() {
+	case C.ConvertorTypeClashRuleProvider:
+		if convertOptions.Metadata.Platform == C.PlatformClash {
+			return cacheKey + "@" + string(C.PlatformClash), nil
+		}
+		return cacheKey, nil
+	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary:
+	default:
+		return cacheKey, nil
+	}
+	version, err := convertor.TargetVersion(convertOptions)
+	if err != nil || version == nil {
+		return cacheKey, err
+	}
+	name := convertor.CompatibilityName(version)
+	if name == "" {
+		return cacheKey, nil
+	}
+	return cacheKey + "@" + name, nil
+}
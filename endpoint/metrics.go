@@ -0,0 +1,258 @@
+package endpoint
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/srsc/adapter"
+)
+
+var (
+	_ http.Handler    = (*MetricsEndpoint)(nil)
+	_ adapter.Metrics = (*MetricsEndpoint)(nil)
+)
+
+// durationBuckets are upper bounds in seconds of duration histograms.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// MetricsEndpoint serves metrics in the Prometheus text format.
+type MetricsEndpoint struct {
+	access             sync.Mutex
+	requests           *metricFamily
+	cacheHits          *metricFamily
+	cacheMisses        *metricFamily
+	fetchDuration      *metricFamily
+	fetchErrors        *metricFamily
+	fetchNotModified   *metricFamily
+	conversionDuration *metricFamily
+	conversionDropped  *metricFamily
+	resourceFetches    *metricFamily
+	families           []*metricFamily
+}
+
+func NewMetricsEndpoint() *MetricsEndpoint {
+	m := &MetricsEndpoint{
+		requests:           newMetricFamily("srsc_http_requests_total", "Requests by endpoint and status.", nil),
+		cacheHits:          newMetricFamily("srsc_cache_hits_total", "Cache hits by backend.", nil),
+		cacheMisses:        newMetricFamily("srsc_cache_misses_total", "Cache misses by backend.", nil),
+		fetchDuration:      newMetricFamily("srsc_source_fetch_duration_seconds", "Duration of source fetches by source.", durationBuckets),
+		fetchErrors:        newMetricFamily("srsc_source_fetch_errors_total", "Failed source fetches by source.", nil),
+		fetchNotModified:   newMetricFamily("srsc_source_not_modified_total", "Source fetches responded not modified by source.", nil),
+		conversionDuration: newMetricFamily("srsc_conversion_duration_seconds", "Duration of conversions by target convertor.", durationBuckets),
+		conversionDropped:  newMetricFamily("srsc_conversion_dropped_items_total", "Items dropped by conversions by target convertor.", nil),
+		resourceFetches:    newMetricFamily("srsc_resource_fetches_total", "Resource fetches by resource and result.", nil),
+	}
+	m.families = []*metricFamily{
+		m.requests,
+		m.cacheHits,
+		m.cacheMisses,
+		m.fetchDuration,
+		m.fetchErrors,
+		m.fetchNotModified,
+		m.conversionDuration,
+		m.conversionDropped,
+		m.resourceFetches,
+	}
+	return m
+}
+
+func (m *MetricsEndpoint) ObserveRequest(endpoint string, status int) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	m.requests.add(1, "endpoint", endpoint, "status", F.ToString(status))
+}
+
+func (m *MetricsEndpoint) ObserveCache(backend string, hit bool) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	if hit {
+		m.cacheHits.add(1, "backend", backend)
+	} else {
+		m.cacheMisses.add(1, "backend", backend)
+	}
+}
+
+func (m *MetricsEndpoint) ObserveFetch(source string, duration time.Duration, notModified bool, err error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	m.fetchDuration.observe(duration.Seconds(), "source", source)
+	if err != nil {
+		m.fetchErrors.add(1, "source", source)
+	} else if notModified {
+		m.fetchNotModified.add(1, "source", source)
+	}
+}
+
+func (m *MetricsEndpoint) ObserveConversion(convertor string, duration time.Duration, dropped int) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	m.conversionDuration.observe(duration.Seconds(), "convertor", convertor)
+	m.conversionDropped.add(float64(dropped), "convertor", convertor)
+}
+
+func (m *MetricsEndpoint) ObserveResource(resource string, err error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.resourceFetches.add(1, "resource", resource, "result", result)
+}
+
+func (m *MetricsEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buffer bytes.Buffer
+	m.access.Lock()
+	for _, family := range m.families {
+		family.write(&buffer)
+	}
+	m.access.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buffer.Bytes())
+}
+
+// Handler returns the handler recording requests of the endpoint by status.
+func (m *MetricsEndpoint) Handler(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statusWriter := &statusResponseWriter{ResponseWriter: w}
+		next(statusWriter, r)
+		status := statusWriter.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.ObserveRequest(endpoint, status)
+	}
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// observeConversion records the conversion started at startedAt if metrics are enabled.
+func observeConversion(metrics adapter.Metrics, convertor string, startedAt time.Time, report *adapter.ConvertReport) {
+	if metrics == nil {
+		return
+	}
+	metrics.ObserveConversion(convertor, time.Since(startedAt), report.Dropped())
+}
+
+// metricFamily is a counter, or a histogram if buckets are set.
+type metricFamily struct {
+	name    string
+	help    string
+	buckets []float64
+	series  map[string]*metricSeries
+}
+
+type metricSeries struct {
+	value        float64
+	count        uint64
+	bucketCounts []uint64
+}
+
+func newMetricFamily(name string, help string, buckets []float64) *metricFamily {
+	return &metricFamily{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		series:  make(map[string]*metricSeries),
+	}
+}
+
+func (f *metricFamily) load(labels []string) *metricSeries {
+	key := formatLabels(labels)
+	series := f.series[key]
+	if series == nil {
+		series = &metricSeries{}
+		if f.buckets != nil {
+			series.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = series
+	}
+	return series
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	f.load(labels).value += value
+}
+
+func (f *metricFamily) observe(value float64, labels ...string) {
+	series := f.load(labels)
+	series.value += value
+	series.count++
+	for i, bound := range f.buckets {
+		if value <= bound {
+			series.bucketCounts[i]++
+		}
+	}
+}
+
+func (f *metricFamily) write(buffer *bytes.Buffer) {
+	metricType := "counter"
+	if f.buckets != nil {
+		metricType = "histogram"
+	}
+	buffer.WriteString("# HELP " + f.name + " " + f.help + "\n")
+	buffer.WriteString("# TYPE " + f.name + " " + metricType + "\n")
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := f.series[key]
+		if f.buckets == nil {
+			buffer.WriteString(f.name + "{" + key + "} " + formatValue(series.value) + "\n")
+			continue
+		}
+		for i, bound := range f.buckets {
+			buffer.WriteString(f.name + "_bucket{" + key + ",le=\"" + formatValue(bound) + "\"} " + F.ToString(series.bucketCounts[i]) + "\n")
+		}
+		buffer.WriteString(f.name + "_bucket{" + key + ",le=\"+Inf\"} " + F.ToString(series.count) + "\n")
+		buffer.WriteString(f.name + "_sum{" + key + "} " + formatValue(series.value) + "\n")
+		buffer.WriteString(f.name + "_count{" + key + "} " + F.ToString(series.count) + "\n")
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+// formatLabels formats label names and values in pairs.
+func formatLabels(labels []string) string {
+	var builder strings.Builder
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(labels[i] + "=\"" + labelValueReplacer.Replace(labels[i+1]) + "\"")
+	}
+	return builder.String()
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
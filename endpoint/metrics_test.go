@@ -0,0 +1,41 @@
+package endpoint
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	t.Parallel()
+	metrics := NewMetricsEndpoint()
+	handler := metrics.Handler("/{code}.srs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cn.srs", nil))
+	metrics.ObserveCache("memory", true)
+	metrics.ObserveCache("memory", false)
+	metrics.ObserveFetch("https://example.com/\"{{ .code }}\".txt", 30*time.Millisecond, true, nil)
+	metrics.ObserveConversion("binary", 2*time.Second, 3)
+	metrics.ObserveResource("geoip", E.New("not found"))
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	content, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	output := string(content)
+	require.Contains(t, output, `srsc_http_requests_total{endpoint="/{code}.srs",status="502"} 1`)
+	require.Contains(t, output, `srsc_cache_hits_total{backend="memory"} 1`)
+	require.Contains(t, output, `srsc_cache_misses_total{backend="memory"} 1`)
+	require.Contains(t, output, `srsc_source_fetch_duration_seconds_bucket{source="https://example.com/\"{{ .code }}\".txt",le="0.05"} 1`)
+	require.Contains(t, output, `srsc_source_fetch_duration_seconds_bucket{source="https://example.com/\"{{ .code }}\".txt",le="0.025"} 0`)
+	require.Contains(t, output, `srsc_source_not_modified_total{source="https://example.com/\"{{ .code }}\".txt"} 1`)
+	require.Contains(t, output, `srsc_conversion_duration_seconds_count{convertor="binary"} 1`)
+	require.Contains(t, output, `srsc_conversion_dropped_items_total{convertor="binary"} 3`)
+	require.Contains(t, output, `srsc_resource_fetches_total{resource="geoip",result="error"} 1`)
+}
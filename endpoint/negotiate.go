@@ -0,0 +1,102 @@
+package endpoint
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+)
+
+// selectTarget selects the target of the request among targets of the endpoint, in the following order:
+// the target or format query parameter, the target or format route parameter, the Accept header,
+// the target type preferred by the detected client platform, and the first target.
+func (f *FileEndpoint) selectTarget(r *http.Request, urlParams map[string]string, metadata C.Metadata) (*fileTarget, error) {
+	if len(f.targets) == 1 {
+		return f.targets[0], nil
+	}
+	query := r.URL.Query()
+	for _, name := range []string{query.Get("target"), query.Get("format"), urlParams["target"], urlParams["format"]} {
+		if name == "" {
+			continue
+		}
+		for _, target := range f.targets {
+			if target.name == name {
+				return target, nil
+			}
+		}
+		return nil, E.New("unknown target: ", name)
+	}
+	for _, mediaType := range acceptedMediaTypes(r.Header.Get("Accept")) {
+		for _, target := range f.targets {
+			contentType, _, _ := mime.ParseMediaType(target.convertor.ContentType(adapter.ConvertOptions{
+				Options:  target.convertOptions,
+				Metadata: metadata,
+			}))
+			if contentType == mediaType {
+				return target, nil
+			}
+		}
+	}
+	for _, target := range f.targets {
+		if platformPrefers(metadata.Platform, target) {
+			return target, nil
+		}
+	}
+	return f.targets[0], nil
+}
+
+// acceptedMediaTypes returns media types in the Accept header by descending quality,
+// wildcard media ranges and media types with zero quality are excluded.
+func acceptedMediaTypes(accept string) []string {
+	type acceptedMediaType struct {
+		mediaType string
+		quality   float64
+	}
+	var mediaTypes []acceptedMediaType
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil || strings.HasSuffix(mediaType, "/*") {
+			continue
+		}
+		quality := 1.0
+		if value, loaded := params["q"]; loaded {
+			quality, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		mediaTypes = append(mediaTypes, acceptedMediaType{mediaType, quality})
+	}
+	sort.SliceStable(mediaTypes, func(i, j int) bool {
+		return mediaTypes[i].quality > mediaTypes[j].quality
+	})
+	return common.Map(mediaTypes, func(it acceptedMediaType) string {
+		return it.mediaType
+	})
+}
+
+// platformPrefers returns whether the target is in a format supported by clients of the platform.
+func platformPrefers(platform C.Platform, target *fileTarget) bool {
+	targetType := target.convertOptions.TargetType
+	switch platform {
+	case C.PlatformSingBox:
+		return targetType == C.ConvertorTypeRuleSetBinary || targetType == C.ConvertorTypeRuleSetSource
+	case C.PlatformMihomo:
+		return targetType == C.ConvertorTypeClashRuleProvider
+	case C.PlatformClash, C.PlatformStash:
+		return targetType == C.ConvertorTypeClashRuleProvider && target.convertOptions.TargetConvertOptions.ClashOptions.TargetFormat != "mrs"
+	case C.PlatformSurge, C.PlatformLoon, C.PlatformShadowrocket:
+		return targetType == C.ConvertorTypeSurgeRuleSet
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectTarget(t *testing.T) {
+	t.Parallel()
+	fileEndpoint, err := NewFileEndpoint(newTestContext(), logger.NOP(), 0, parseEndpoint(t, `{
+  "type": "file",
+  "source": "local",
+  "path": "rules.json",
+  "source_type": "source",
+  "targets": [
+    {"target_type": "binary"},
+    {"target_type": "source"},
+    {"name": "mrs", "target_type": "clash", "target_format": "mrs", "target_behavior": "domain"},
+    {"name": "text", "target_type": "clash", "target_format": "text", "target_behavior": "domain"}
+  ]
+}`).FileOptions)
+	require.NoError(t, err)
+	selectTarget := func(path string, urlParams map[string]string, header http.Header) string {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		metadata, err := detectMetadata(request)
+		require.NoError(t, err)
+		target, err := fileEndpoint.selectTarget(request, urlParams, metadata)
+		if err != nil {
+			return ""
+		}
+		return target.name
+	}
+	require.Equal(t, "binary", selectTarget("/rules", nil, nil))
+	require.Equal(t, "mrs", selectTarget("/rules?format=mrs", nil, nil))
+	require.Equal(t, "source", selectTarget("/rules?target=source&format=mrs", nil, nil))
+	require.Equal(t, "text", selectTarget("/rules", map[string]string{"format": "text"}, nil))
+	require.Equal(t, "", selectTarget("/rules?format=unknown", nil, nil))
+	require.Equal(t, "source", selectTarget("/rules", nil, http.Header{"Accept": {"application/json"}}))
+	require.Equal(t, "text", selectTarget("/rules", nil, http.Header{"Accept": {"text/plain;q=0.5, application/x-unknown, application/json;q=0.4"}}))
+	require.Equal(t, "source", selectTarget("/rules", nil, http.Header{"Accept": {"text/plain;q=0, application/json;q=0.1, */*"}}))
+	require.Equal(t, "binary", selectTarget("/rules", nil, http.Header{"Accept": {"text/*"}}))
+	require.Equal(t, "text", selectTarget("/rules", nil, http.Header{"User-Agent": {"ClashX/1.118.0"}}))
+	require.Equal(t, "mrs", selectTarget("/rules", nil, http.Header{"User-Agent": {"mihomo/1.19.0"}}))
+	require.Equal(t, "binary", selectTarget("/rules", nil, http.Header{"User-Agent": {"SFA/1.11.0 (sing-box 1.11.0)"}}))
+	require.Equal(t, "source", selectTarget("/rules", nil, http.Header{
+		"User-Agent": {"mihomo/1.19.0"},
+		"Accept":     {"application/json"},
+	}))
+}
+
+func TestTargetETag(t *testing.T) {
+	t.Parallel()
+	sourcePath := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(`{"version": 3, "rules": [{"domain_suffix": ["example.com"]}]}`), 0o644))
+	fileEndpoint, err := NewFileEndpoint(newTestContext(), logger.NOP(), 0, parseEndpoint(t, `{
+  "type": "file",
+  "source": "local",
+  "path": "`+sourcePath+`",
+  "source_type": "source",
+  "targets": [
+    {"target_type": "source"},
+    {"name": "text", "target_type": "clash", "target_format": "text", "target_behavior": "domain"}
+  ]
+}`).FileOptions)
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	router.Get("/rules", fileEndpoint.ServeHTTP)
+	request := func(path string, ifNoneMatch string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	recorder := request("/rules", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	sourceETag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, sourceETag)
+	recorder = request("/rules?format=text", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	textETag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, textETag)
+	require.NotEqual(t, sourceETag, textETag)
+	recorder = request("/rules?format=text", textETag)
+	require.Equal(t, http.StatusNotModified, recorder.Code)
+	require.Empty(t, recorder.Body.Bytes())
+	recorder = request("/rules?format=text", sourceETag)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotEmpty(t, recorder.Body.Bytes())
+	recorder = request("/rules", textETag)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, sourceETag, recorder.Header().Get("ETag"))
+}
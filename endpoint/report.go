@@ -0,0 +1,36 @@
+package endpoint
+
+import (
+	"net/http"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/srsc/adapter"
+)
+
+// writeReport stores the conversion report of the request path and the selected target and exposes it in response headers,
+// in strict mode the request fails if anything was dropped.
+func writeReport(w http.ResponseWriter, r *http.Request, reportStore adapter.ConvertReportStore, target string, report *adapter.ConvertReport, strict bool) error {
+	if reportStore != nil {
+		reportStore.StoreReport(reportKey(r.URL.Path, target), report)
+	}
+	dropped := report.Dropped()
+	if dropped == 0 {
+		return nil
+	}
+	if strict {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return E.New("dropped ", dropped, " items in strict mode: ", report.String())
+	}
+	w.Header().Set("X-Convert-Dropped", F.ToString(dropped))
+	w.Header().Set("X-Convert-Report", report.String())
+	return nil
+}
+
+// reportKey returns the key of reports of the path, since endpoints with multiple targets serve different reports for the same path.
+func reportKey(path string, target string) string {
+	if target == "" {
+		return path
+	}
+	return path + " (" + target + ")"
+}
@@ -0,0 +1,50 @@
+package endpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/srsc/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+	debugEndpoint := NewDebugEndpoint()
+	var report adapter.ConvertReport
+	report.Drop("rules with path", 2, "||example.com/path^")
+	request := httptest.NewRequest(http.MethodGet, "/rules", nil)
+	recorder := httptest.NewRecorder()
+	require.NoError(t, writeReport(recorder, request, debugEndpoint, "binary", &report, false))
+	require.Equal(t, "2", recorder.Header().Get("X-Convert-Dropped"))
+	require.Equal(t, "rules with path: 2", recorder.Header().Get("X-Convert-Report"))
+	recorder = httptest.NewRecorder()
+	require.Error(t, writeReport(recorder, request, debugEndpoint, "", &report, true))
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	recorder = httptest.NewRecorder()
+	require.NoError(t, writeReport(recorder, request, debugEndpoint, "source", nil, true))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get("X-Convert-Dropped"))
+	recorder = httptest.NewRecorder()
+	debugEndpoint.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/reports", nil))
+	var reports map[string][]adapter.ConvertReportItem
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &reports))
+	require.Equal(t, map[string][]adapter.ConvertReportItem{
+		"/rules (binary)": report.Items(),
+		"/rules":          report.Items(),
+	}, reports)
+	for i := 0; i < maxStoredReports; i++ {
+		debugEndpoint.StoreReport(F.ToString("/", i, ".srs"), &report)
+	}
+	var count int
+	debugEndpoint.reports.Range(func(path string, report *adapter.ConvertReport) {
+		count++
+	})
+	require.Equal(t, maxStoredReports, count)
+	_, loaded := debugEndpoint.reports.Load("/rules")
+	require.False(t, loaded)
+}
@@ -0,0 +1,224 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+	"github.com/sagernet/srsc/ruleset"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var _ http.Handler = (*SetEndpoint)(nil)
+
+type SetEndpoint struct {
+	ctx             context.Context
+	logger          logger.ContextLogger
+	cache           adapter.Cache
+	reportStore     adapter.ConvertReportStore
+	metrics         adapter.Metrics
+	index           int
+	operation       string
+	targetConvertor adapter.Convertor
+	convertOptions  option.ConvertOptions
+	operands        []setOperand
+}
+
+type setOperand struct {
+	path      string
+	endpoint  *FileEndpoint
+	urlParams map[string]string
+}
+
+// NewSetEndpoint creates a endpoint that computes the union, intersection or difference of file endpoints,
+// operands are resolved by matching their paths against the file endpoints registered in the router.
+func NewSetEndpoint(ctx context.Context, logger logger.ContextLogger, index int, options option.SetEndpoint, router *chi.Mux, fileEndpoints map[string]*FileEndpoint) (*SetEndpoint, error) {
+	ep := &SetEndpoint{
+		ctx:         ctx,
+		logger:      logger,
+		cache:       service.FromContext[adapter.Cache](ctx),
+		reportStore: service.FromContext[adapter.ConvertReportStore](ctx),
+		metrics:     service.FromContext[adapter.Metrics](ctx),
+		index:       index,
+		operation:   options.Operation,
+		convertOptions: option.ConvertOptions{
+			TargetConvertOptions: options.TargetOptions,
+		},
+	}
+	switch options.Operation {
+	case C.SetOperationUnion, C.SetOperationIntersection, C.SetOperationDifference:
+	case "":
+		return nil, E.New("missing operation")
+	default:
+		return nil, E.New("unknown operation: ", options.Operation)
+	}
+	targetConvertor, loaded := convertor.Convertors[options.TargetOptions.TargetType]
+	if !loaded {
+		return nil, E.New("unknown target type: ", options.TargetOptions.TargetType)
+	}
+	ep.targetConvertor = targetConvertor
+	err := checkTargetVersion(options.TargetOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Endpoints) < 2 {
+		return nil, E.New("at least two endpoints are required")
+	}
+	for _, path := range options.Endpoints {
+		fileEndpoint, urlParams, err := findFileEndpoint(router, fileEndpoints, path)
+		if err != nil {
+			return nil, err
+		}
+		ep.operands = append(ep.operands, setOperand{
+			path:      path,
+			endpoint:  fileEndpoint,
+			urlParams: urlParams,
+		})
+	}
+	return ep, nil
+}
+
+// RefreshSources fetches sources of referenced file endpoints ignoring the cache.
+func (s *SetEndpoint) RefreshSources(_ map[string]string) ([]string, error) {
+	var sourcePaths []string
+	for _, operand := range s.operands {
+		refreshedPaths, err := operand.endpoint.RefreshSources(operand.urlParams)
+		if err != nil {
+			return nil, E.Cause(err, operand.path)
+		}
+		sourcePaths = append(sourcePaths, refreshedPaths...)
+	}
+	return sourcePaths, nil
+}
+
+func (s *SetEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := s.serveHTTP0(w, r)
+	if err != nil {
+		s.logger.Error("handle ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", err)
+	} else {
+		s.logger.Debug("accepted ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\"")
+	}
+}
+
+func (s *SetEndpoint) serveHTTP0(w http.ResponseWriter, r *http.Request) error {
+	metadata, err := detectMetadata(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	convertOptions := adapter.ConvertOptions{
+		Options:  s.convertOptions,
+		Metadata: metadata,
+	}
+	sourceBinaries := make([]*adapter.SavedBinary, 0, len(s.operands))
+	var lastUpdated time.Time
+	hash := sha256.New()
+	for _, operand := range s.operands {
+		sourceBinary, err := operand.endpoint.FetchSource(operand.urlParams)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return E.Cause(err, "fetch ", operand.path)
+		}
+		sourceBinaries = append(sourceBinaries, sourceBinary)
+		if sourceBinary.LastUpdated.After(lastUpdated) {
+			lastUpdated = sourceBinary.LastUpdated
+		}
+		sourceDigest, err := operand.endpoint.SourceDigest(sourceBinary, operand.urlParams)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return E.Cause(err, "fetch ", operand.path)
+		}
+		hash.Write([]byte(F.ToString(operand.path, "\n", sourceDigest, "\n")))
+	}
+	setDigest := hex.EncodeToString(hash.Sum(nil)[:16])
+	setEtag := "\"" + setDigest + "\""
+	cacheKey, err := versionedCacheKey(cacheKeyPrefix(s.ctx, C.EndpointTypeSet, s.index), s.targetConvertor, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+	cachedBinary, err := s.cache.LoadBinary(cacheKey)
+	if err != nil && !os.IsNotExist(err) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "load cache binary")
+	}
+	if cachedBinary != nil && cachedBinary.LastEtag == setEtag {
+		err = writeReport(w, r, s.reportStore, "", cachedBinary.Report, s.convertOptions.Strict)
+		if err != nil {
+			return err
+		}
+		return s.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+	}
+	releaseConversion, acquired := acquireConversion(w, r)
+	if !acquired {
+		return nil
+	}
+	defer releaseConversion()
+	startedAt := time.Now()
+	convertOptions.Report = &adapter.ConvertReport{}
+	ruleSets := make([][]adapter.Rule, 0, len(s.operands))
+	for index, operand := range s.operands {
+		rules, err := operand.endpoint.Rules(s.ctx, sourceBinaries[index], operand.urlParams, convertOptions.Metadata, convertOptions.Report)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return E.Cause(err, operand.path)
+		}
+		ruleSets = append(ruleSets, rules)
+	}
+	rules, err := ruleset.Operate(s.operation, ruleSets, convertOptions.Report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, s.operation)
+	}
+	if s.convertOptions.Optimize {
+		rules, err = convertor.Optimize(rules)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return E.Cause(err, "optimize rules")
+		}
+	}
+	binary, err := s.targetConvertor.To(s.ctx, rules, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "encode target")
+	}
+	observeConversion(s.metrics, s.convertOptions.TargetType, startedAt, convertOptions.Report)
+	if convertOptions.Report.Dropped() > 0 {
+		s.logger.Warn("dropped ", convertOptions.Report.Dropped(), " items converting ", r.URL.Path, ": ", convertOptions.Report.String())
+	} else {
+		convertOptions.Report = nil
+	}
+	cachedBinary = &adapter.SavedBinary{
+		Content:      binary,
+		LastUpdated:  lastUpdated,
+		LastEtag:     setEtag,
+		Report:       convertOptions.Report,
+		SourceDigest: setDigest,
+	}
+	err = s.cache.SaveBinary(cacheKey, cachedBinary)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "save cache binary")
+	}
+	err = writeReport(w, r, s.reportStore, "", cachedBinary.Report, s.convertOptions.Strict)
+	if err != nil {
+		return err
+	}
+	return s.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+}
+
+func (s *SetEndpoint) writeCache(w http.ResponseWriter, r *http.Request, cacheKey string, cachedBinary *adapter.SavedBinary, convertOptions adapter.ConvertOptions) error {
+	return writeContent(w, r, s.cache, cacheKey, cachedBinary, s.targetConvertor.ContentType(convertOptions))
+}
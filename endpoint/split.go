@@ -0,0 +1,252 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"time"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var _ http.Handler = (*SplitEndpoint)(nil)
+
+type SplitEndpoint struct {
+	ctx             context.Context
+	logger          logger.ContextLogger
+	cache           adapter.Cache
+	reportStore     adapter.ConvertReportStore
+	metrics         adapter.Metrics
+	index           int
+	part            string
+	targetConvertor adapter.Convertor
+	convertOptions  option.ConvertOptions
+	path            string
+	endpoint        *FileEndpoint
+	urlParams       map[string]string
+}
+
+// NewSplitEndpoint creates a endpoint that serves the domain or IP CIDR part of rules of a file endpoint,
+// the file endpoint is resolved by matching the path against the file endpoints registered in the router.
+func NewSplitEndpoint(ctx context.Context, logger logger.ContextLogger, index int, options option.SplitEndpoint, router *chi.Mux, fileEndpoints map[string]*FileEndpoint) (*SplitEndpoint, error) {
+	ep := &SplitEndpoint{
+		ctx:         ctx,
+		logger:      logger,
+		cache:       service.FromContext[adapter.Cache](ctx),
+		reportStore: service.FromContext[adapter.ConvertReportStore](ctx),
+		metrics:     service.FromContext[adapter.Metrics](ctx),
+		index:       index,
+		part:        options.Part,
+		convertOptions: option.ConvertOptions{
+			TargetConvertOptions: options.TargetOptions,
+		},
+		path: options.Endpoint,
+	}
+	switch options.Part {
+	case C.SplitPartDomain, C.SplitPartIPCIDR:
+	case "":
+		return nil, E.New("missing part")
+	default:
+		return nil, E.New("unknown part: ", options.Part)
+	}
+	targetConvertor, loaded := convertor.Convertors[options.TargetOptions.TargetType]
+	if !loaded {
+		return nil, E.New("unknown target type: ", options.TargetOptions.TargetType)
+	}
+	ep.targetConvertor = targetConvertor
+	err := checkTargetVersion(options.TargetOptions)
+	if err != nil {
+		return nil, err
+	}
+	if options.Endpoint == "" {
+		return nil, E.New("missing endpoint")
+	}
+	fileEndpoint, urlParams, err := findFileEndpoint(router, fileEndpoints, options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ep.endpoint = fileEndpoint
+	ep.urlParams = urlParams
+	return ep, nil
+}
+
+// RefreshSources fetches the source of the referenced file endpoint ignoring the cache.
+func (s *SplitEndpoint) RefreshSources(_ map[string]string) ([]string, error) {
+	sourcePaths, err := s.endpoint.RefreshSources(s.urlParams)
+	if err != nil {
+		return nil, E.Cause(err, s.path)
+	}
+	return sourcePaths, nil
+}
+
+func (s *SplitEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := s.serveHTTP0(w, r)
+	if err != nil {
+		s.logger.Error("handle ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\": ", err)
+	} else {
+		s.logger.Debug("accepted ", r.RemoteAddr, " - ", r.Header.Get("User-Agent"), " \"", r.Method, " ", r.URL, " ", r.Proto, "\"")
+	}
+}
+
+func (s *SplitEndpoint) serveHTTP0(w http.ResponseWriter, r *http.Request) error {
+	metadata, err := detectMetadata(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	convertOptions := adapter.ConvertOptions{
+		Options:  s.convertOptions,
+		Metadata: metadata,
+	}
+	sourceBinary, err := s.endpoint.FetchSource(s.urlParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return E.Cause(err, "fetch ", s.path)
+	}
+	sourceDigest, err := s.endpoint.SourceDigest(sourceBinary, s.urlParams)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return E.Cause(err, s.path)
+	}
+	splitEtag := s.etag(sourceDigest)
+	cacheKey, err := versionedCacheKey(cacheKeyPrefix(s.ctx, C.EndpointTypeSplit, s.index), s.targetConvertor, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+	cachedBinary, err := s.cache.LoadBinary(cacheKey)
+	if err != nil && !os.IsNotExist(err) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "load cache binary")
+	}
+	if cachedBinary != nil && cachedBinary.LastEtag == splitEtag {
+		err = writeReport(w, r, s.reportStore, "", cachedBinary.Report, s.convertOptions.Strict)
+		if err != nil {
+			return err
+		}
+		return s.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+	}
+	releaseConversion, acquired := acquireConversion(w, r)
+	if !acquired {
+		return nil
+	}
+	defer releaseConversion()
+	startedAt := time.Now()
+	split, err := s.endpoint.loadSplitRules(s.ctx, sourceBinary, sourceDigest, s.urlParams, convertOptions.Metadata)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, s.path)
+	}
+	convertOptions.Report = split.report
+	rules := split.rules(s.part)
+	if s.convertOptions.Optimize {
+		rules, err = convertor.Optimize(rules)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return E.Cause(err, "optimize rules")
+		}
+	}
+	binary, err := s.targetConvertor.To(s.ctx, rules, convertOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "encode target")
+	}
+	observeConversion(s.metrics, s.convertOptions.TargetType, startedAt, convertOptions.Report)
+	if convertOptions.Report.Dropped() > 0 {
+		s.logger.Warn("dropped ", convertOptions.Report.Dropped(), " items converting ", r.URL.Path, ": ", convertOptions.Report.String())
+	} else {
+		convertOptions.Report = nil
+	}
+	cachedBinary = &adapter.SavedBinary{
+		Content:      binary,
+		LastUpdated:  sourceBinary.LastUpdated,
+		LastEtag:     s.etag(split.sourceDigest),
+		Report:       convertOptions.Report,
+		SourceDigest: split.sourceDigest,
+	}
+	err = s.cache.SaveBinary(cacheKey, cachedBinary)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return E.Cause(err, "save cache binary")
+	}
+	err = writeReport(w, r, s.reportStore, "", cachedBinary.Report, s.convertOptions.Strict)
+	if err != nil {
+		return err
+	}
+	return s.writeCache(w, r, cacheKey, cachedBinary, convertOptions)
+}
+
+func (s *SplitEndpoint) writeCache(w http.ResponseWriter, r *http.Request, cacheKey string, cachedBinary *adapter.SavedBinary, convertOptions adapter.ConvertOptions) error {
+	return writeContent(w, r, s.cache, cacheKey, cachedBinary, s.targetConvertor.ContentType(convertOptions))
+}
+
+// splitRules is the domain and IP CIDR parts of rules of a file endpoint.
+type splitRules struct {
+	sourceDigest string
+	domain       adapter.DefaultRule
+	ipCIDR       adapter.DefaultRule
+	report       *adapter.ConvertReport
+}
+
+func (s *SplitEndpoint) etag(sourceDigest string) string {
+	hash := sha256.New()
+	hash.Write([]byte(F.ToString(s.path, "\n", sourceDigest, "\n")))
+	return "\"" + hex.EncodeToString(hash.Sum(nil)[:16]) + "\""
+}
+
+func (f *FileEndpoint) loadSplitRules(ctx context.Context, sourceBinary *adapter.SavedBinary, sourceDigest string, urlParams map[string]string, metadata C.Metadata) (*splitRules, error) {
+	decoded, err := f.loadRules(ctx, sourceBinary, sourceDigest, urlParams, metadata)
+	if err != nil {
+		return nil, err
+	}
+	split := &splitRules{
+		sourceDigest: decoded.sourceDigest,
+		report:       &adapter.ConvertReport{},
+	}
+	split.report.Merge(decoded.report)
+	for _, rule := range decoded.rules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) {
+			split.report.Drop("rules fitting neither domain nor ipcidr part", 1)
+			continue
+		}
+		split.domain.Domain = append(split.domain.Domain, rule.DefaultOptions.Domain...)
+		split.domain.DomainSuffix = append(split.domain.DomainSuffix, rule.DefaultOptions.DomainSuffix...)
+		split.domain.DomainKeyword = append(split.domain.DomainKeyword, rule.DefaultOptions.DomainKeyword...)
+		split.domain.DomainRegex = append(split.domain.DomainRegex, rule.DefaultOptions.DomainRegex...)
+		split.ipCIDR.IPCIDR = append(split.ipCIDR.IPCIDR, rule.DefaultOptions.IPCIDR...)
+		split.ipCIDR.GEOIP = append(split.ipCIDR.GEOIP, rule.DefaultOptions.GEOIP...)
+		split.ipCIDR.IPASN = append(split.ipCIDR.IPASN, rule.DefaultOptions.IPASN...)
+	}
+	return split, nil
+}
+
+func (s *splitRules) rules(part string) []adapter.Rule {
+	rule := s.ipCIDR
+	if part == C.SplitPartDomain {
+		rule = s.domain
+	}
+	if !hasItems(rule) {
+		return nil
+	}
+	return []adapter.Rule{{
+		Type:           boxConstant.RuleTypeDefault,
+		DefaultOptions: rule,
+	}}
+}
+
+func hasItems(rule adapter.DefaultRule) bool {
+	return len(rule.Domain) > 0 || len(rule.DomainSuffix) > 0 || len(rule.DomainKeyword) > 0 || len(rule.DomainRegex) > 0 ||
+		len(rule.IPCIDR) > 0 || len(rule.GEOIP) > 0 || len(rule.IPASN) > 0
+}
@@ -0,0 +1,97 @@
+package endpoint
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/cache"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContext() context.Context {
+	ctx := service.ContextWithDefaultRegistry(context.Background())
+	service.MustRegister[adapter.Cache](ctx, cache.NewMemory(0))
+	return ctx
+}
+
+func parseEndpoint(t *testing.T, content string) option.Endpoint {
+	var endpoint option.Endpoint
+	require.NoError(t, json.Unmarshal([]byte(content), &endpoint))
+	return endpoint
+}
+
+func serve(handler http.HandlerFunc, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestSplitEndpoint(t *testing.T) {
+	t.Parallel()
+	ctx := newTestContext()
+	sourcePath := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(sourcePath, []byte(`{
+  "version": 3,
+  "rules": [
+    {"domain": ["example.com"], "domain_suffix": [".example.org"], "ip_cidr": ["1.1.1.0/24"]},
+    {"ip_cidr": ["2001:db8::/32"]},
+    {"domain": ["port.example.com"], "port": [443]}
+  ]
+}`), 0o644))
+	fileEndpoint, err := NewFileEndpoint(ctx, logger.NOP(), 0, parseEndpoint(t, `{
+  "type": "file",
+  "source": "local",
+  "path": "`+sourcePath+`",
+  "source_type": "source",
+  "target_type": "source"
+}`).FileOptions)
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	router.Get("/rules.json", fileEndpoint.ServeHTTP)
+	fileEndpoints := map[string]*FileEndpoint{"/rules.json": fileEndpoint}
+	newSplit := func(index int, part string) *SplitEndpoint {
+		splitEndpoint, err := NewSplitEndpoint(ctx, logger.NOP(), index, parseEndpoint(t, `{
+  "type": "split",
+  "endpoint": "/rules.json",
+  "part": "`+part+`",
+  "target_type": "source"
+}`).SplitOptions, router, fileEndpoints)
+		require.NoError(t, err)
+		return splitEndpoint
+	}
+	domainEndpoint := newSplit(1, "domain")
+	ipCIDREndpoint := newSplit(2, "ipcidr")
+	recorder := serve(domainEndpoint.ServeHTTP, "/domain.json")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"version": 3, "rules": [{"domain": "example.com", "domain_suffix": ".example.org"}]}`, recorder.Body.String())
+	require.Equal(t, "1", recorder.Header().Get("X-Convert-Dropped"))
+	etag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	recorder = serve(ipCIDREndpoint.ServeHTTP, "/ipcidr.json")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"version": 3, "rules": [{"ip_cidr": ["1.1.1.0/24", "2001:db8::/32"]}]}`, recorder.Body.String())
+	recorder = serve(domainEndpoint.ServeHTTP, "/domain.json")
+	require.Equal(t, etag, recorder.Header().Get("ETag"))
+	require.Equal(t, "1", recorder.Header().Get("X-Convert-Dropped"))
+	require.NoError(t, os.WriteFile(sourcePath, []byte(`{"version": 3, "rules": [{"domain": "example.net"}]}`), 0o644))
+	require.NoError(t, os.Chtimes(sourcePath, time.Now(), time.Now().Add(time.Second)))
+	recorder = serve(domainEndpoint.ServeHTTP, "/domain.json")
+	require.JSONEq(t, `{"version": 3, "rules": [{"domain": "example.net"}]}`, recorder.Body.String())
+	require.NotEqual(t, etag, recorder.Header().Get("ETag"))
+	recorder = serve(ipCIDREndpoint.ServeHTTP, "/ipcidr.json")
+	require.JSONEq(t, `{"version": 3}`, recorder.Body.String())
+	_, err = NewSplitEndpoint(ctx, logger.NOP(), 3, option.SplitEndpoint{Part: "domain", Endpoint: "/other.json"}, router, fileEndpoints)
+	require.Error(t, err)
+}
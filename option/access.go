@@ -0,0 +1,79 @@
+package option
+
+import (
+	"os"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type AccessOptions struct {
+	Tokens   []Secret                                  `json:"tokens,omitempty"`
+	Users    []AccessUser                              `json:"users,omitempty"`
+	AllowIPs badoption.Listable[*badoption.Prefixable] `json:"allow_ips,omitempty"`
+}
+
+type AccessUser struct {
+	Username string `json:"username,omitempty"`
+	Password Secret `json:"password,omitempty"`
+}
+
+type _Secret struct {
+	Value string `json:"-"`
+	Env   string `json:"env,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+// Secret is a literal string, or an object referencing an environment variable or a file.
+type Secret _Secret
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	if s.Env == "" && s.File == "" {
+		return json.Marshal(s.Value)
+	}
+	return json.Marshal(_Secret(s))
+}
+
+func (s *Secret) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, &s.Value)
+	if err == nil {
+		return nil
+	}
+	err = json.Unmarshal(bytes, (*_Secret)(s))
+	if err != nil {
+		return err
+	}
+	if (s.Env == "") == (s.File == "") {
+		return E.New("exactly one of env and file is required")
+	}
+	return nil
+}
+
+// Load returns the value of the secret, trailing newlines of files are removed.
+func (s Secret) Load() (string, error) {
+	switch {
+	case s.Env != "":
+		value, loaded := os.LookupEnv(s.Env)
+		if !loaded || value == "" {
+			return "", E.New("missing environment variable: ", s.Env)
+		}
+		return value, nil
+	case s.File != "":
+		content, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", E.Cause(err, "read secret file")
+		}
+		value := strings.TrimRight(string(content), "\r\n")
+		if value == "" {
+			return "", E.New("empty secret file: ", s.File)
+		}
+		return value, nil
+	default:
+		if s.Value == "" {
+			return "", E.New("empty secret")
+		}
+		return s.Value, nil
+	}
+}
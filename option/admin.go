@@ -0,0 +1,6 @@
+package option
+
+type AdminOptions struct {
+	Path   string         `json:"path,omitempty"`
+	Access *AccessOptions `json:"access,omitempty"`
+}
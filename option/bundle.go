@@ -0,0 +1,25 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badjson"
+)
+
+type _BundleEndpoint struct {
+	Categories    *badjson.TypedMap[string, string] `json:"categories,omitempty"`
+	TargetOptions TargetConvertOptions              `json:"-"`
+}
+
+type BundleEndpoint _BundleEndpoint
+
+func (e BundleEndpoint) MarshalJSON() ([]byte, error) {
+	return badjson.MarshallObjects((_BundleEndpoint)(e), e.TargetOptions)
+}
+
+func (e *BundleEndpoint) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_BundleEndpoint)(e))
+	if err != nil {
+		return err
+	}
+	return badjson.UnmarshallExcluded(bytes, (*_BundleEndpoint)(e), &e.TargetOptions)
+}
@@ -4,6 +4,7 @@ import (
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
 	C "github.com/sagernet/srsc/constant"
 )
 
@@ -25,7 +26,7 @@ func (o *ConvertOptions) UnmarshalJSON(bytes []byte) error {
 }
 
 func (o *ConvertOptions) ConvertRequired() bool {
-	if o.SourceType != o.TargetType {
+	if o.SourceType != o.TargetType || o.Optimize || o.RuleSetOptions.TargetVersion != "" {
 		return true
 	}
 	switch o.SourceType {
@@ -43,6 +44,9 @@ type _SourceConvertOptions struct {
 	AdGuardOptions AdGuardRuleSetSourceOptions    `json:"-"`
 	ClashOptions   ClashRuleProviderSourceOptions `json:"-"`
 	SurgeOptions   SurgeRuleProviderSourceOptions `json:"-"`
+	V2RayOptions   V2RayDatSourceOptions          `json:"-"`
+	MMDBOptions    MMDBSourceOptions              `json:"-"`
+	SingBoxOptions SingBoxDatabaseSourceOptions   `json:"-"`
 }
 
 type SourceConvertOptions _SourceConvertOptions
@@ -50,13 +54,19 @@ type SourceConvertOptions _SourceConvertOptions
 func (o SourceConvertOptions) MarshalJSON() ([]byte, error) {
 	var v any
 	switch o.SourceType {
-	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary:
+	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary, C.ConvertorTypeHosts, C.ConvertorTypeDomainList, C.ConvertorTypeIPList:
 	case C.ConvertorTypeAdGuardRuleSet:
 		v = o.AdGuardOptions
 	case C.ConvertorTypeClashRuleProvider:
 		v = o.ClashOptions
 	case C.ConvertorTypeSurgeRuleSet:
 		v = o.SurgeOptions
+	case C.ConvertorTypeV2RayGeoSite, C.ConvertorTypeV2RayGeoIP:
+		v = o.V2RayOptions
+	case C.ConvertorTypeMMDB:
+		v = o.MMDBOptions
+	case C.ConvertorTypeSingBoxGeoSite, C.ConvertorTypeSingBoxGeoIP:
+		v = o.SingBoxOptions
 	case "":
 		return nil, E.New("missing source type")
 	default:
@@ -75,13 +85,19 @@ func (o *SourceConvertOptions) UnmarshalJSON(bytes []byte) error {
 	}
 	var v any
 	switch o.SourceType {
-	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary:
+	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary, C.ConvertorTypeHosts, C.ConvertorTypeDomainList, C.ConvertorTypeIPList:
 	case C.ConvertorTypeAdGuardRuleSet:
 		v = &o.AdGuardOptions
 	case C.ConvertorTypeClashRuleProvider:
 		v = &o.ClashOptions
 	case C.ConvertorTypeSurgeRuleSet:
 		v = &o.SurgeOptions
+	case C.ConvertorTypeV2RayGeoSite, C.ConvertorTypeV2RayGeoIP:
+		v = &o.V2RayOptions
+	case C.ConvertorTypeMMDB:
+		v = &o.MMDBOptions
+	case C.ConvertorTypeSingBoxGeoSite, C.ConvertorTypeSingBoxGeoIP:
+		v = &o.SingBoxOptions
 	case "":
 		return E.New("missing source type")
 	default:
@@ -94,9 +110,17 @@ func (o *SourceConvertOptions) UnmarshalJSON(bytes []byte) error {
 }
 
 type _TargetConvertOptions struct {
-	TargetType   string                         `json:"target_type,omitempty"`
-	ClashOptions ClashRuleProviderTargetOptions `json:"-"`
-	SurgeOptions SurgeRuleProviderTargetOptions `json:"-"`
+	TargetType         string                         `json:"target_type,omitempty"`
+	Strict             bool                           `json:"strict,omitempty"`
+	Optimize           bool                           `json:"optimize,omitempty"`
+	RuleSetOptions     RuleSetTargetOptions           `json:"-"`
+	ClashOptions       ClashRuleProviderTargetOptions `json:"-"`
+	SurgeOptions       SurgeRuleProviderTargetOptions `json:"-"`
+	DnsmasqOptions     DnsmasqTargetOptions           `json:"-"`
+	SmartDNSOptions    SmartDNSTargetOptions          `json:"-"`
+	UnboundOptions     UnboundTargetOptions           `json:"-"`
+	AdGuardHomeOptions AdGuardHomeTargetOptions       `json:"-"`
+	V2RayOptions       V2RayDatTargetOptions          `json:"-"`
 }
 
 type TargetConvertOptions _TargetConvertOptions
@@ -105,10 +129,22 @@ func (o TargetConvertOptions) MarshalJSON() ([]byte, error) {
 	var v any
 	switch o.TargetType {
 	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary:
+		v = o.RuleSetOptions
+	case C.ConvertorTypeHosts, C.ConvertorTypeDomainList, C.ConvertorTypeIPList:
 	case C.ConvertorTypeClashRuleProvider:
 		v = o.ClashOptions
 	case C.ConvertorTypeSurgeRuleSet:
 		v = o.SurgeOptions
+	case C.ConvertorTypeDnsmasq:
+		v = o.DnsmasqOptions
+	case C.ConvertorTypeSmartDNS:
+		v = o.SmartDNSOptions
+	case C.ConvertorTypeUnbound:
+		v = o.UnboundOptions
+	case C.ConvertorTypeAdGuardHome:
+		v = o.AdGuardHomeOptions
+	case C.ConvertorTypeV2RayGeoSite, C.ConvertorTypeV2RayGeoIP:
+		v = o.V2RayOptions
 	case "":
 		return nil, E.New("missing target type")
 	default:
@@ -128,10 +164,22 @@ func (o *TargetConvertOptions) UnmarshalJSON(bytes []byte) error {
 	var v any
 	switch o.TargetType {
 	case C.ConvertorTypeRuleSetSource, C.ConvertorTypeRuleSetBinary:
+		v = &o.RuleSetOptions
+	case C.ConvertorTypeHosts, C.ConvertorTypeDomainList, C.ConvertorTypeIPList:
 	case C.ConvertorTypeClashRuleProvider:
 		v = &o.ClashOptions
 	case C.ConvertorTypeSurgeRuleSet:
 		v = &o.SurgeOptions
+	case C.ConvertorTypeDnsmasq:
+		v = &o.DnsmasqOptions
+	case C.ConvertorTypeSmartDNS:
+		v = &o.SmartDNSOptions
+	case C.ConvertorTypeUnbound:
+		v = &o.UnboundOptions
+	case C.ConvertorTypeAdGuardHome:
+		v = &o.AdGuardHomeOptions
+	case C.ConvertorTypeV2RayGeoSite, C.ConvertorTypeV2RayGeoIP:
+		v = &o.V2RayOptions
 	case "":
 		return E.New("missing target type")
 	default:
@@ -152,6 +200,22 @@ type ClashRuleProviderSourceOptions struct {
 	SourceBehavior string `json:"source_behavior,omitempty"`
 }
 
+type V2RayDatSourceOptions struct {
+	Code string `json:"code,omitempty"`
+}
+
+type MMDBSourceOptions struct {
+	Code string `json:"code,omitempty"`
+}
+
+type SingBoxDatabaseSourceOptions struct {
+	Code string `json:"code,omitempty"`
+}
+
+type RuleSetTargetOptions struct {
+	TargetVersion string `json:"target_version,omitempty"`
+}
+
 type ClashRuleProviderTargetOptions struct {
 	TargetFormat   string `json:"target_format,omitempty"`
 	TargetBehavior string `json:"target_behavior,omitempty"`
@@ -164,3 +228,25 @@ type SurgeRuleProviderSourceOptions struct {
 type SurgeRuleProviderTargetOptions struct {
 	TargetBehavior string `json:"target_behavior,omitempty"`
 }
+
+type DnsmasqTargetOptions struct {
+	Upstream badoption.Listable[string] `json:"upstream,omitempty"`
+	IPSet    badoption.Listable[string] `json:"ipset,omitempty"`
+	NFTSet   badoption.Listable[string] `json:"nftset,omitempty"`
+}
+
+type SmartDNSTargetOptions struct {
+	Group string `json:"group,omitempty"`
+}
+
+type UnboundTargetOptions struct {
+	ZoneType string `json:"zone_type,omitempty"`
+}
+
+type AdGuardHomeTargetOptions struct {
+	Upstream badoption.Listable[string] `json:"upstream,omitempty"`
+}
+
+type V2RayDatTargetOptions struct {
+	Code string `json:"code,omitempty"`
+}
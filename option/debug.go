@@ -0,0 +1,5 @@
+package option
+
+type DebugOptions struct {
+	Path string `json:"path,omitempty"`
+}
@@ -12,22 +12,66 @@ import (
 )
 
 type _FileEndpoint struct {
-	SourceOptions
-	ConvertOptions
+	Transforms     []RuleTransform `json:"transforms,omitempty"`
+	Targets        []FileTarget    `json:"targets,omitempty"`
+	SourceOptions  `json:"-"`
+	ConvertOptions `json:"-"`
 }
 
 type FileEndpoint _FileEndpoint
 
 func (e FileEndpoint) MarshalJSON() ([]byte, error) {
-	return badjson.MarshallObjects(e.SourceOptions, e.ConvertOptions)
+	if len(e.Targets) > 0 {
+		return badjson.MarshallObjects((_FileEndpoint)(e), e.SourceOptions, e.SourceConvertOptions)
+	}
+	return badjson.MarshallObjects((_FileEndpoint)(e), e.SourceOptions, e.ConvertOptions)
 }
 
 func (e *FileEndpoint) UnmarshalJSON(bytes []byte) error {
-	err := json.Unmarshal(bytes, &e.SourceOptions)
+	err := json.Unmarshal(bytes, (*_FileEndpoint)(e))
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(bytes, &e.SourceOptions)
+	if err != nil {
+		return err
+	}
+	if len(e.Targets) > 0 {
+		err = json.Unmarshal(bytes, &e.SourceConvertOptions)
+		if err != nil {
+			return err
+		}
+		var parentContent []byte
+		parentContent, err = badjson.MarshallObjects((_FileEndpoint)(*e), e.SourceOptions, e.SourceConvertOptions)
+		if err != nil {
+			return err
+		}
+		return badjson.UnmarshallExcludedMulti(bytes, json.RawMessage(parentContent), nil)
+	}
+	parentContent, err := badjson.MarshallObjects((_FileEndpoint)(*e), e.SourceOptions)
+	if err != nil {
+		return err
+	}
+	return badjson.UnmarshallExcludedMulti(bytes, json.RawMessage(parentContent), &e.ConvertOptions)
+}
+
+type _FileTarget struct {
+	Name          string               `json:"name,omitempty"`
+	TargetOptions TargetConvertOptions `json:"-"`
+}
+
+type FileTarget _FileTarget
+
+func (t FileTarget) MarshalJSON() ([]byte, error) {
+	return badjson.MarshallObjects((_FileTarget)(t), t.TargetOptions)
+}
+
+func (t *FileTarget) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_FileTarget)(t))
 	if err != nil {
 		return err
 	}
-	return badjson.UnmarshallExcludedMulti(bytes, &e.SourceOptions, &e.ConvertOptions)
+	return badjson.UnmarshallExcluded(bytes, (*_FileTarget)(t), &t.TargetOptions)
 }
 
 type _SourceOptions struct {
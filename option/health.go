@@ -0,0 +1,7 @@
+package option
+
+type HealthOptions struct {
+	LivenessPath  string   `json:"liveness_path,omitempty"`
+	ReadinessPath string   `json:"readiness_path,omitempty"`
+	Endpoints     []string `json:"endpoints,omitempty"`
+}
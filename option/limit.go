@@ -0,0 +1,10 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type LimitOptions struct {
+	Interval                 badoption.Duration `json:"interval,omitempty"`
+	Burst                    int                `json:"burst,omitempty"`
+	By                       string             `json:"by,omitempty"`
+	MaxConcurrentConversions int                `json:"max_concurrent_conversions,omitempty"`
+}
@@ -0,0 +1,5 @@
+package option
+
+type MetricsOptions struct {
+	Path string `json:"path,omitempty"`
+}
@@ -13,11 +13,19 @@ import (
 )
 
 type _Options struct {
-	Log        *option.LogOptions                   `json:"log,omitempty"`
-	Listen     *badoption.Addr                      `json:"listen,omitempty"`
-	ListenPort uint16                               `json:"listen_port,omitempty"`
-	Endpoints  *badjson.TypedMap[string, *Endpoint] `json:"endpoints,omitempty"`
-	Resources  *ResourceOptions                     `json:"resources,omitempty"`
+	Log             *option.LogOptions                   `json:"log,omitempty"`
+	Listen          *badoption.Addr                      `json:"listen,omitempty"`
+	ListenPort      uint16                               `json:"listen_port,omitempty"`
+	ShutdownTimeout badoption.Duration                   `json:"shutdown_timeout,omitempty"`
+	Endpoints       *badjson.TypedMap[string, *Endpoint] `json:"endpoints,omitempty"`
+	Resources       *ResourceOptions                     `json:"resources,omitempty"`
+	RuleSet         *RuleSetOptions                      `json:"rule_set,omitempty"`
+	Debug           *DebugOptions                        `json:"debug,omitempty"`
+	Metrics         *MetricsOptions                      `json:"metrics,omitempty"`
+	Admin           *AdminOptions                        `json:"admin,omitempty"`
+	Health          *HealthOptions                       `json:"health,omitempty"`
+	Access          *AccessOptions                       `json:"access,omitempty"`
+	Limit           *LimitOptions                        `json:"limit,omitempty"`
 	option.InboundTLSOptionsContainer
 	Cache      *CacheOptions `json:"cache,omitempty"`
 	RawMessage []byte        `json:"-"`
@@ -37,8 +45,12 @@ func (o *Options) UnmarshalJSONContext(ctx context.Context, content []byte) erro
 }
 
 type _Endpoint struct {
-	Type        string       `json:"type,omitempty"`
-	FileOptions FileEndpoint `json:"-"`
+	Type          string         `json:"type,omitempty"`
+	Access        *AccessOptions `json:"access,omitempty"`
+	FileOptions   FileEndpoint   `json:"-"`
+	BundleOptions BundleEndpoint `json:"-"`
+	SetOptions    SetEndpoint    `json:"-"`
+	SplitOptions  SplitEndpoint  `json:"-"`
 }
 
 type Endpoint _Endpoint
@@ -48,6 +60,12 @@ func (o Endpoint) MarshalJSON() ([]byte, error) {
 	switch o.Type {
 	case C.EndpointTypeFile:
 		v = o.FileOptions
+	case C.EndpointTypeBundle:
+		v = o.BundleOptions
+	case C.EndpointTypeSet:
+		v = o.SetOptions
+	case C.EndpointTypeSplit:
+		v = o.SplitOptions
 	case "":
 		return nil, E.New("missing endpoint type")
 	default:
@@ -65,6 +83,12 @@ func (o *Endpoint) UnmarshalJSON(bytes []byte) error {
 	switch o.Type {
 	case C.EndpointTypeFile:
 		v = &o.FileOptions
+	case C.EndpointTypeBundle:
+		v = &o.BundleOptions
+	case C.EndpointTypeSet:
+		v = &o.SetOptions
+	case C.EndpointTypeSplit:
+		v = &o.SplitOptions
 	default:
 		return E.New("unknown endpoint type: " + o.Type)
 	}
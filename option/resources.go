@@ -3,6 +3,7 @@ package option
 import (
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
 )
 
 type ResourceOptions struct {
@@ -27,3 +28,9 @@ func (e *Resource) UnmarshalJSON(bytes []byte) error {
 	}
 	return badjson.UnmarshallExcludedMulti(bytes, &e.SourceOptions, &e.SourceConvertOptions)
 }
+
+type RuleSetOptions struct {
+	Sources    *badjson.TypedMap[string, *Resource] `json:"sources,omitempty"`
+	AllowedURL badoption.Listable[string]           `json:"allowed_url,omitempty"`
+	MaxDepth   int                                  `json:"max_depth,omitempty"`
+}
@@ -0,0 +1,27 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type _SetEndpoint struct {
+	Operation     string                     `json:"operation,omitempty"`
+	Endpoints     badoption.Listable[string] `json:"endpoints,omitempty"`
+	TargetOptions TargetConvertOptions       `json:"-"`
+}
+
+type SetEndpoint _SetEndpoint
+
+func (e SetEndpoint) MarshalJSON() ([]byte, error) {
+	return badjson.MarshallObjects((_SetEndpoint)(e), e.TargetOptions)
+}
+
+func (e *SetEndpoint) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_SetEndpoint)(e))
+	if err != nil {
+		return err
+	}
+	return badjson.UnmarshallExcluded(bytes, (*_SetEndpoint)(e), &e.TargetOptions)
+}
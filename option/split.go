@@ -0,0 +1,26 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badjson"
+)
+
+type _SplitEndpoint struct {
+	Endpoint      string               `json:"endpoint,omitempty"`
+	Part          string               `json:"part,omitempty"`
+	TargetOptions TargetConvertOptions `json:"-"`
+}
+
+type SplitEndpoint _SplitEndpoint
+
+func (e SplitEndpoint) MarshalJSON() ([]byte, error) {
+	return badjson.MarshallObjects((_SplitEndpoint)(e), e.TargetOptions)
+}
+
+func (e *SplitEndpoint) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_SplitEndpoint)(e))
+	if err != nil {
+		return err
+	}
+	return badjson.UnmarshallExcluded(bytes, (*_SplitEndpoint)(e), &e.TargetOptions)
+}
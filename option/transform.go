@@ -0,0 +1,99 @@
+package option
+
+import (
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/common/json/badoption"
+	C "github.com/sagernet/srsc/constant"
+)
+
+type _RuleTransform struct {
+	Type              string                `json:"type,omitempty"`
+	FilterOptions     FilterRuleTransform   `json:"-"`
+	AddOptions        AddRuleTransform      `json:"-"`
+	DropFieldsOptions DropFieldsTransform   `json:"-"`
+	LogicalOptions    LogicalRuleTransform  `json:"-"`
+	SubtractOptions   SubtractRuleTransform `json:"-"`
+}
+
+type RuleTransform _RuleTransform
+
+func (t RuleTransform) MarshalJSON() ([]byte, error) {
+	var v any
+	switch t.Type {
+	case C.TransformTypeInclude, C.TransformTypeExclude:
+		v = t.FilterOptions
+	case C.TransformTypeAdd:
+		v = t.AddOptions
+	case C.TransformTypeDropFields:
+		v = t.DropFieldsOptions
+	case C.TransformTypeInvert:
+	case C.TransformTypeLogical:
+		v = t.LogicalOptions
+	case C.TransformTypeSubtract:
+		v = t.SubtractOptions
+	case "":
+		return nil, E.New("missing transform type")
+	default:
+		return nil, E.New("unknown transform type: " + t.Type)
+	}
+	if v == nil {
+		return json.Marshal((_RuleTransform)(t))
+	}
+	return badjson.MarshallObjects((_RuleTransform)(t), v)
+}
+
+func (t *RuleTransform) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_RuleTransform)(t))
+	if err != nil {
+		return err
+	}
+	var v any
+	switch t.Type {
+	case C.TransformTypeInclude, C.TransformTypeExclude:
+		v = &t.FilterOptions
+	case C.TransformTypeAdd:
+		v = &t.AddOptions
+	case C.TransformTypeDropFields:
+		v = &t.DropFieldsOptions
+	case C.TransformTypeInvert:
+	case C.TransformTypeLogical:
+		v = &t.LogicalOptions
+	case C.TransformTypeSubtract:
+		v = &t.SubtractOptions
+	case "":
+		return E.New("missing transform type")
+	default:
+		return E.New("unknown transform type: " + t.Type)
+	}
+	return badjson.UnmarshallExcluded(bytes, (*_RuleTransform)(t), v)
+}
+
+type FilterRuleTransform struct {
+	Domain        badoption.Listable[string] `json:"domain,omitempty"`
+	DomainSuffix  badoption.Listable[string] `json:"domain_suffix,omitempty"`
+	DomainKeyword badoption.Listable[string] `json:"domain_keyword,omitempty"`
+	DomainRegex   badoption.Listable[string] `json:"domain_regex,omitempty"`
+	IPCIDR        badoption.Listable[string] `json:"ip_cidr,omitempty"`
+}
+
+type AddRuleTransform struct {
+	Rules []option.HeadlessRule `json:"rules,omitempty"`
+}
+
+type DropFieldsTransform struct {
+	Fields badoption.Listable[string] `json:"fields,omitempty"`
+}
+
+type LogicalRuleTransform struct {
+	Mode   string                `json:"mode,omitempty"`
+	Rules  []option.HeadlessRule `json:"rules,omitempty"`
+	Invert bool                  `json:"invert,omitempty"`
+}
+
+type SubtractRuleTransform struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	RuleSet  string `json:"rule_set,omitempty"`
+}
@@ -3,6 +3,7 @@ package resource
 import (
 	"context"
 	"os"
+	"sync/atomic"
 
 	boxConstant "github.com/sagernet/sing-box/constant"
 	boxOption "github.com/sagernet/sing-box/option"
@@ -22,6 +23,7 @@ type Manager struct {
 	ctx     context.Context
 	logger  logger.ContextLogger
 	cache   adapter.Cache
+	metrics adapter.Metrics
 	geoip   *Resource
 	geosite *Resource
 	ipasn   *Resource
@@ -31,6 +33,7 @@ type Resource struct {
 	adapter.Source
 	adapter.Convertor
 	option.SourceConvertOptions
+	loaded atomic.Bool
 }
 
 func NewResource(ctx context.Context, options *option.Resource) (*Resource, error) {
@@ -51,9 +54,10 @@ func NewResource(ctx context.Context, options *option.Resource) (*Resource, erro
 
 func NewManager(ctx context.Context, logger logger.ContextLogger, options option.ResourceOptions) (*Manager, error) {
 	m := &Manager{
-		ctx:    ctx,
-		logger: logger,
-		cache:  service.FromContext[adapter.Cache](ctx),
+		ctx:     ctx,
+		logger:  logger,
+		cache:   service.FromContext[adapter.Cache](ctx),
+		metrics: service.FromContext[adapter.Metrics](ctx),
 	}
 	if options.GEOIP != nil {
 		geoip, err := NewResource(ctx, options.GEOIP)
@@ -87,13 +91,16 @@ func (m *Manager) GEOIP(code string) (*boxOption.DefaultHeadlessRule, error) {
 	if m.geoip == nil {
 		return nil, E.New("GEOIP resource source is not configured")
 	}
-	cachePath, err := m.geoip.Path(map[string]string{
+	params := map[string]string{
 		"code": code,
-	})
+	}
+	cachePath, err := m.geoip.Path(params)
 	if err != nil {
 		return nil, E.Cause(err, "evaluate source path")
 	}
-	return m.fetch(m.geoip, cachePath, "res.geoip."+cachePath)
+	rule, err := m.fetch(m.geoip, cachePath, "res.geoip.", params)
+	m.observe("geoip", code, err)
+	return rule, err
 }
 
 func (m *Manager) GEOSiteConfigured() bool {
@@ -104,13 +111,16 @@ func (m *Manager) GEOSite(code string) (*boxOption.DefaultHeadlessRule, error) {
 	if m.geosite == nil {
 		return nil, E.New("GEOSite resource source is not configured")
 	}
-	cachePath, err := m.geosite.Path(map[string]string{
+	params := map[string]string{
 		"code": code,
-	})
+	}
+	cachePath, err := m.geosite.Path(params)
 	if err != nil {
 		return nil, E.Cause(err, "evaluate source path")
 	}
-	return m.fetch(m.geosite, cachePath, "res.geosite."+cachePath)
+	rule, err := m.fetch(m.geosite, cachePath, "res.geosite.", params)
+	m.observe("geosite", code, err)
+	return rule, err
 }
 
 func (m *Manager) IPASNConfigured() bool {
@@ -121,54 +131,142 @@ func (m *Manager) IPASN(asn string) (*boxOption.DefaultHeadlessRule, error) {
 	if m.ipasn == nil {
 		return nil, E.New("IPASN resource source is not configured")
 	}
-	cachePath, err := m.ipasn.Path(map[string]string{
+	params := map[string]string{
 		"asn": asn,
-	})
+	}
+	cachePath, err := m.ipasn.Path(params)
 	if err != nil {
 		return nil, E.Cause(err, "evaluate source path")
 	}
-	return m.fetch(m.ipasn, cachePath, "res.ipasn."+cachePath)
+	rule, err := m.fetch(m.ipasn, cachePath, "res.ipasn.", params)
+	m.observe("ipasn", asn, err)
+	return rule, err
 }
 
-func (m *Manager) fetch(r *Resource, cachePath string, cacheKey string) (*boxOption.DefaultHeadlessRule, error) {
-	cachedBinary, err := m.cache.LoadBinary(cacheKey)
-	if err != nil && !os.IsNotExist(err) {
-		return nil, E.Cause(err, "load cache binary")
+// Refresh fetches the source of the resource code ignoring the cache and converts it again,
+// the refreshed source path is returned.
+func (m *Manager) Refresh(resource string, code string) (string, error) {
+	r, paramName := m.resource(resource)
+	if r == nil {
+		return "", E.New("resource is not configured: ", resource)
 	}
-	lastUpdated := r.LastUpdated(cachePath)
-	if cachedBinary != nil && !lastUpdated.IsZero() && cachedBinary.LastUpdated.Equal(lastUpdated) {
-		return m.loadCache(cachedBinary)
+	params := map[string]string{
+		paramName: code,
 	}
-	var fetchBody adapter.FetchRequestBody
-	if cachedBinary != nil {
-		fetchBody.ETag = cachedBinary.LastEtag
-		fetchBody.LastUpdated = cachedBinary.LastUpdated
+	cachePath, err := r.Path(params)
+	if err != nil {
+		return "", E.Cause(err, "evaluate source path")
+	}
+	_, err = source.Refresh(m.cache, r.Source, cachePath)
+	if err != nil {
+		return "", err
 	}
-	response, err := r.Fetch(cachePath, fetchBody)
+	_, err = m.fetch(r, cachePath, "res."+resource+".", params)
 	if err != nil {
-		return nil, E.Cause(err, "fetch source")
+		return "", err
 	}
-	if response.NotModified {
-		if cachedBinary == nil {
-			return nil, E.New("fetch source: unexpected not modified response")
+	return cachePath, nil
+}
+
+// Preload fetches sources of resources using a single database for all codes,
+// sources of other resources depend on codes and are fetched on demand.
+func (m *Manager) Preload() error {
+	for _, name := range []string{"geoip", "geosite", "ipasn"} {
+		r, paramName := m.resource(name)
+		if r == nil {
+			continue
 		}
-		if response.LastUpdated != cachedBinary.LastUpdated {
-			cachedBinary.LastUpdated = response.LastUpdated
-			err = m.cache.SaveBinary(cacheKey, cachedBinary)
-			if err != nil {
-				return nil, E.Cause(err, "save cache binary")
-			}
+		sourcePath, shared, err := sharedPath(r, paramName)
+		if err != nil {
+			return E.Cause(err, "preload ", name)
 		}
-		return m.loadCache(cachedBinary)
+		if !shared {
+			continue
+		}
+		_, err = source.Fetch(m.cache, r.Source, sourcePath)
+		if err != nil {
+			return E.Cause(err, "preload ", name)
+		}
+		r.loaded.Store(true)
 	}
-	if len(response.Content) == 0 {
-		return nil, E.Cause(err, "fetch source: empty content")
+	return nil
+}
+
+// CheckLoaded returns an error if a resource using a single database for all codes has never been loaded.
+func (m *Manager) CheckLoaded() error {
+	for _, name := range []string{"geoip", "geosite", "ipasn"} {
+		r, paramName := m.resource(name)
+		if r == nil || r.loaded.Load() {
+			continue
+		}
+		_, shared, err := sharedPath(r, paramName)
+		if err != nil {
+			return E.Cause(err, name)
+		}
+		if shared {
+			return E.New(name, " resource not loaded")
+		}
+	}
+	return nil
+}
+
+// resource returns the configured resource of the name and the name of its URL parameter.
+func (m *Manager) resource(name string) (*Resource, string) {
+	switch name {
+	case "geoip":
+		return m.geoip, "code"
+	case "geosite":
+		return m.geosite, "code"
+	case "ipasn":
+		return m.ipasn, "asn"
+	default:
+		return nil, ""
+	}
+}
+
+// sharedPath returns the source path of the resource, and whether it is shared by all codes.
+func sharedPath(r *Resource, paramName string) (string, bool, error) {
+	path, err := r.Path(map[string]string{paramName: "0"})
+	if err != nil {
+		return "", false, E.Cause(err, "evaluate source path")
+	}
+	otherPath, err := r.Path(map[string]string{paramName: "1"})
+	if err != nil {
+		return "", false, E.Cause(err, "evaluate source path")
+	}
+	return path, path == otherPath, nil
+}
+
+// observe records the fetch in metrics, failures are logged with the code,
+// which is left out of metric labels since codes are requested by clients and unbounded.
+func (m *Manager) observe(resource string, code string, err error) {
+	if err != nil {
+		m.logger.Warn(E.Cause(err, "fetch ", resource, " ", code))
+	}
+	if m.metrics != nil {
+		m.metrics.ObserveResource(resource, err)
+	}
+}
+
+func (m *Manager) fetch(r *Resource, cachePath string, cacheKey string, params map[string]string) (*boxOption.DefaultHeadlessRule, error) {
+	sourceBinary, err := source.Fetch(m.cache, r.Source, cachePath)
+	if err != nil {
+		return nil, err
+	}
+	r.loaded.Store(true)
+	cacheKey = source.CacheKey(cacheKey, cachePath, params)
+	cachedBinary, err := m.cache.LoadBinary(cacheKey)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, E.Cause(err, "load cache binary")
+	}
+	if source.IsDerived(cachedBinary, sourceBinary) {
+		return m.loadCache(cachedBinary)
 	}
-	var rules []adapter.Rule
-	rules, err = r.From(m.ctx, response.Content, adapter.ConvertOptions{
+	rules, err := r.From(m.ctx, sourceBinary.Content, adapter.ConvertOptions{
 		Options: option.ConvertOptions{
 			SourceConvertOptions: r.SourceConvertOptions,
 		},
+		Params: params,
 	})
 	if err != nil {
 		return nil, E.Cause(err, "decode source")
@@ -177,6 +275,8 @@ func (m *Manager) fetch(r *Resource, cachePath string, cacheKey string) (*boxOpt
 		return nil, E.New("unexpected resource rule count: ", len(rules))
 	} else if rules[0].Type != boxConstant.RuleTypeDefault {
 		return nil, E.New("unexpected complex resource: logical rule")
+	} else if rules[0].DefaultOptions.Invert {
+		return nil, E.New("unexpected complex resource: inverted rule")
 	} else if !rules[0].Headlessable() {
 		return nil, E.New("unexpected complex resource: unsupported by sing-box")
 	}
@@ -187,9 +287,10 @@ func (m *Manager) fetch(r *Resource, cachePath string, cacheKey string) (*boxOpt
 		return nil, E.Cause(err, "encode JSON")
 	}
 	cachedBinary = &adapter.SavedBinary{
-		Content:     binary,
-		LastUpdated: response.LastUpdated,
-		LastEtag:    response.ETag,
+		Content:      binary,
+		LastUpdated:  sourceBinary.LastUpdated,
+		LastEtag:     sourceBinary.LastEtag,
+		SourceDigest: source.DerivedDigest(sourceBinary),
 	}
 	err = m.cache.SaveBinary(cacheKey, cachedBinary)
 	if err != nil {
@@ -0,0 +1,53 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/cache"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLoaded(t *testing.T) {
+	t.Parallel()
+	ctx := service.ContextWithDefaultRegistry(context.Background())
+	service.MustRegister[adapter.Cache](ctx, cache.NewMemory(0))
+	directory := t.TempDir()
+	databasePath := filepath.Join(directory, "geoip.mmdb")
+	require.NoError(t, os.WriteFile(databasePath, []byte("database"), 0o644))
+	var options option.ResourceOptions
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "geoip": {
+    "source": "local",
+    "path": "`+databasePath+`",
+    "source_type": "mmdb"
+  },
+  "geosite": {
+    "source": "local",
+    "path": "`+filepath.Join(directory, "{{ .code }}.json")+`",
+    "source_type": "source"
+  }
+}`), &options))
+	manager, err := NewManager(ctx, logger.NOP(), options)
+	require.NoError(t, err)
+
+	path, shared, err := sharedPath(manager.geoip, "code")
+	require.NoError(t, err)
+	require.True(t, shared)
+	require.Equal(t, databasePath, path)
+	_, shared, err = sharedPath(manager.geosite, "code")
+	require.NoError(t, err)
+	require.False(t, shared)
+
+	require.EqualError(t, manager.CheckLoaded(), "geoip resource not loaded")
+	require.NoError(t, manager.Preload())
+	require.NoError(t, manager.CheckLoaded())
+}
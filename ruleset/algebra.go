@@ -0,0 +1,360 @@
+package ruleset
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+
+	"go4.org/netipx"
+)
+
+// Operate computes the union, intersection or difference of destination address rules of rule-sets,
+// the difference is the first rule-set minus all others.
+func Operate(operation string, ruleSets [][]adapter.Rule, report *adapter.ConvertReport) ([]adapter.Rule, error) {
+	if len(ruleSets) == 0 {
+		return nil, E.New("missing rule-sets")
+	}
+	sets := make([]*destinationSet, 0, len(ruleSets))
+	for index, rules := range ruleSets {
+		set, err := newDestinationSet(rules)
+		if err != nil {
+			return nil, E.Cause(err, "rule-set[", index, "]")
+		}
+		sets = append(sets, set)
+	}
+	switch operation {
+	case C.SetOperationUnion:
+		result := sets[0]
+		for _, set := range sets[1:] {
+			err := result.union(set)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result.toRules()
+	case C.SetOperationIntersection:
+		for _, set := range sets {
+			set.dropUnsupported(report, operation)
+		}
+		result := sets[0]
+		for _, set := range sets[1:] {
+			var err error
+			result, err = result.intersection(set)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result.toRules()
+	case C.SetOperationDifference:
+		for _, set := range sets {
+			set.dropUnsupported(report, operation)
+		}
+		if len(sets) == 1 {
+			return sets[0].toRules()
+		}
+		subtrahend := sets[1]
+		for _, set := range sets[2:] {
+			err := subtrahend.union(set)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return sets[0].difference(subtrahend)
+	default:
+		return nil, E.New("unknown set operation: ", operation)
+	}
+}
+
+// destinationSet is the set of addresses matched by destination address rules,
+// a domain_suffix item without leading dot is stored as both the domain and its subdomains.
+type destinationSet struct {
+	domains    map[string]bool
+	subdomains map[string]bool
+	keywords   []string
+	regexes    []string
+	ipSet      netipx.IPSetBuilder
+	otherRules []adapter.Rule
+}
+
+func newDestinationSet(rules []adapter.Rule) (*destinationSet, error) {
+	set := &destinationSet{
+		domains:    make(map[string]bool),
+		subdomains: make(map[string]bool),
+	}
+	for _, rule := range rules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) ||
+			len(rule.DefaultOptions.GEOIP) > 0 || len(rule.DefaultOptions.IPASN) > 0 {
+			set.otherRules = append(set.otherRules, rule)
+			continue
+		}
+		for _, domain := range rule.DefaultOptions.Domain {
+			set.domains[normalizeDomain(domain)] = true
+		}
+		for _, suffix := range rule.DefaultOptions.DomainSuffix {
+			suffix = normalizeDomain(suffix)
+			if strings.HasPrefix(suffix, ".") {
+				set.subdomains[suffix[1:]] = true
+			} else {
+				set.domains[suffix] = true
+				set.subdomains[suffix] = true
+			}
+		}
+		set.keywords = append(set.keywords, rule.DefaultOptions.DomainKeyword...)
+		set.regexes = append(set.regexes, rule.DefaultOptions.DomainRegex...)
+		for _, ipCIDR := range rule.DefaultOptions.IPCIDR {
+			prefix, err := netip.ParsePrefix(ipCIDR)
+			if err == nil {
+				set.ipSet.AddPrefix(prefix)
+				continue
+			}
+			addr, addrErr := netip.ParseAddr(ipCIDR)
+			if addrErr != nil {
+				return nil, E.Cause(err, "parse ip_cidr")
+			}
+			set.ipSet.Add(addr)
+		}
+	}
+	return set, nil
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(domain), ".")
+}
+
+// dropUnsupported reports and removes items that cannot be computed exactly by the operation.
+func (s *destinationSet) dropUnsupported(report *adapter.ConvertReport, operation string) {
+	report.Drop("domain_keyword items unsupported by "+operation, len(s.keywords), s.keywords...)
+	report.Drop("domain_regex items unsupported by "+operation, len(s.regexes), s.regexes...)
+	report.Drop("rules with non-destination address items unsupported by "+operation, len(s.otherRules))
+	s.keywords = nil
+	s.regexes = nil
+	s.otherRules = nil
+}
+
+// contains returns whether the domain is in the set.
+func (s *destinationSet) contains(domain string) bool {
+	return s.domains[domain] || s.containsParent(domain)
+}
+
+// containsSubdomains returns whether all subdomains of the domain are in the set.
+func (s *destinationSet) containsSubdomains(domain string) bool {
+	return s.subdomains[domain] || s.containsParent(domain)
+}
+
+func (s *destinationSet) containsParent(domain string) bool {
+	for {
+		index := strings.IndexByte(domain, '.')
+		if index == -1 {
+			return false
+		}
+		domain = domain[index+1:]
+		if s.subdomains[domain] {
+			return true
+		}
+	}
+}
+
+func (s *destinationSet) union(other *destinationSet) error {
+	for domain := range other.domains {
+		s.domains[domain] = true
+	}
+	for domain := range other.subdomains {
+		s.subdomains[domain] = true
+	}
+	s.keywords = append(s.keywords, other.keywords...)
+	s.regexes = append(s.regexes, other.regexes...)
+	otherIPSet, err := other.ipSet.IPSet()
+	if err != nil {
+		return err
+	}
+	s.ipSet.AddSet(otherIPSet)
+	s.otherRules = append(s.otherRules, other.otherRules...)
+	return nil
+}
+
+func (s *destinationSet) intersection(other *destinationSet) (*destinationSet, error) {
+	result := &destinationSet{
+		domains:    make(map[string]bool),
+		subdomains: make(map[string]bool),
+	}
+	for _, pair := range [][2]*destinationSet{{s, other}, {other, s}} {
+		for domain := range pair[0].domains {
+			if pair[1].contains(domain) {
+				result.domains[domain] = true
+			}
+		}
+		for domain := range pair[0].subdomains {
+			if pair[1].containsSubdomains(domain) {
+				result.subdomains[domain] = true
+			}
+		}
+	}
+	ipSet, err := s.ipSet.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	otherIPSet, err := other.ipSet.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	result.ipSet.AddSet(ipSet)
+	result.ipSet.Intersect(otherIPSet)
+	return result, nil
+}
+
+// difference returns rules matching the set minus the other set.
+// Subdomains partially covered by the other set are expressed as logical rules.
+func (s *destinationSet) difference(other *destinationSet) ([]adapter.Rule, error) {
+	result := &destinationSet{
+		domains:    make(map[string]bool),
+		subdomains: make(map[string]bool),
+		keywords:   s.keywords,
+		regexes:    s.regexes,
+	}
+	for domain := range s.domains {
+		if !other.contains(domain) {
+			result.domains[domain] = true
+		}
+	}
+	excludedDomains := make(map[string][]string)
+	excludedSubdomains := make(map[string][]string)
+	for domain := range s.subdomains {
+		if !other.containsSubdomains(domain) {
+			result.subdomains[domain] = true
+		}
+	}
+	for _, pair := range []struct {
+		items    map[string]bool
+		excluded map[string][]string
+	}{{other.domains, excludedDomains}, {other.subdomains, excludedSubdomains}} {
+		for item := range pair.items {
+			for parent := item; ; {
+				index := strings.IndexByte(parent, '.')
+				if index == -1 {
+					break
+				}
+				parent = parent[index+1:]
+				if result.subdomains[parent] {
+					pair.excluded[parent] = append(pair.excluded[parent], item)
+				}
+			}
+		}
+	}
+	ipSet, err := s.ipSet.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	otherIPSet, err := other.ipSet.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	result.ipSet.AddSet(ipSet)
+	result.ipSet.RemoveSet(otherIPSet)
+	var exceptionRules []adapter.Rule
+	for _, domain := range sortedKeys(result.subdomains) {
+		if len(excludedDomains[domain]) == 0 && len(excludedSubdomains[domain]) == 0 {
+			continue
+		}
+		delete(result.subdomains, domain)
+		exception := &destinationSet{
+			domains:    make(map[string]bool),
+			subdomains: make(map[string]bool),
+		}
+		for _, excludedDomain := range excludedDomains[domain] {
+			exception.domains[excludedDomain] = true
+		}
+		for _, excludedSubdomain := range excludedSubdomains[domain] {
+			exception.subdomains[excludedSubdomain] = true
+		}
+		exceptionRule, err := exception.toDefaultRule()
+		if err != nil {
+			return nil, err
+		}
+		exceptionRule.Invert = true
+		exceptionRules = append(exceptionRules, adapter.Rule{
+			Type: boxConstant.RuleTypeLogical,
+			LogicalOptions: adapter.LogicalRule{
+				Mode: boxConstant.LogicalTypeAnd,
+				Rules: []adapter.Rule{
+					{
+						Type: boxConstant.RuleTypeDefault,
+						DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+							DomainSuffix: []string{"." + domain},
+						}),
+					},
+					{
+						Type:           boxConstant.RuleTypeDefault,
+						DefaultOptions: exceptionRule,
+					},
+				},
+			},
+		})
+	}
+	rules, err := result.toRules()
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, exceptionRules...), nil
+}
+
+func (s *destinationSet) toRules() ([]adapter.Rule, error) {
+	var rules []adapter.Rule
+	defaultRule, err := s.toDefaultRule()
+	if err != nil {
+		return nil, err
+	}
+	if len(defaultRule.Domain) > 0 || len(defaultRule.DomainSuffix) > 0 || len(defaultRule.DomainKeyword) > 0 ||
+		len(defaultRule.DomainRegex) > 0 || len(defaultRule.IPCIDR) > 0 {
+		rules = append(rules, adapter.Rule{
+			Type:           boxConstant.RuleTypeDefault,
+			DefaultOptions: defaultRule,
+		})
+	}
+	return append(rules, s.otherRules...), nil
+}
+
+// toDefaultRule returns the destination address rule of the set with covered items removed.
+func (s *destinationSet) toDefaultRule() (adapter.DefaultRule, error) {
+	var rule adapter.DefaultRule
+	for _, domain := range sortedKeys(s.domains) {
+		if s.containsParent(domain) {
+			continue
+		}
+		if s.subdomains[domain] {
+			rule.DomainSuffix = append(rule.DomainSuffix, domain)
+		} else {
+			rule.Domain = append(rule.Domain, domain)
+		}
+	}
+	for _, domain := range sortedKeys(s.subdomains) {
+		if s.domains[domain] || s.containsParent(domain) {
+			continue
+		}
+		rule.DomainSuffix = append(rule.DomainSuffix, "."+domain)
+	}
+	rule.DomainKeyword = s.keywords
+	rule.DomainRegex = s.regexes
+	ipSet, err := s.ipSet.IPSet()
+	if err != nil {
+		return adapter.DefaultRule{}, err
+	}
+	for _, prefix := range ipSet.Prefixes() {
+		rule.IPCIDR = append(rule.IPCIDR, prefix.String())
+	}
+	return rule, nil
+}
+
+func sortedKeys(items map[string]bool) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package ruleset
+
+import (
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func destinationRules(domains, domainSuffixes, ipCIDRs []string) []adapter.Rule {
+	return []adapter.Rule{{
+		Type: boxConstant.RuleTypeDefault,
+		DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+			Domain:       domains,
+			DomainSuffix: domainSuffixes,
+			IPCIDR:       ipCIDRs,
+		}),
+	}}
+}
+
+func TestSetOperations(t *testing.T) {
+	t.Parallel()
+	ruleSetA := destinationRules([]string{"a.example.org", "example.net"}, []string{"example.com", ".example.org"}, []string{"10.0.0.0/8"})
+	ruleSetB := destinationRules([]string{"www.example.com", "example.org"}, []string{"cdn.example.com", "example.net"}, []string{"10.0.0.0/9", "192.168.0.0/16"})
+
+	rules, err := Operate(C.SetOperationUnion, [][]adapter.Rule{ruleSetA, ruleSetB}, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Empty(t, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.com", "example.net", "example.org"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/8", "192.168.0.0/16"}, rules[0].DefaultOptions.IPCIDR)
+
+	rules, err = Operate(C.SetOperationIntersection, [][]adapter.Rule{ruleSetA, ruleSetB}, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"example.net", "www.example.com"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"cdn.example.com"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/9"}, rules[0].DefaultOptions.IPCIDR)
+
+	rules, err = Operate(C.SetOperationDifference, [][]adapter.Rule{ruleSetA, ruleSetB}, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, badoption.Listable[string]{"example.com"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{".example.org"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"10.128.0.0/9"}, rules[0].DefaultOptions.IPCIDR)
+	require.Equal(t, boxConstant.RuleTypeLogical, rules[1].Type)
+	exceptionRule := rules[1].LogicalOptions.Rules[1].DefaultOptions
+	require.True(t, exceptionRule.Invert)
+	require.Equal(t, badoption.Listable[string]{"www.example.com"}, exceptionRule.Domain)
+	require.Equal(t, badoption.Listable[string]{"cdn.example.com"}, exceptionRule.DomainSuffix)
+}
@@ -0,0 +1,206 @@
+package ruleset
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/sagernet/sing/common/cache"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/convertor"
+	"github.com/sagernet/srsc/option"
+	"github.com/sagernet/srsc/source"
+)
+
+const DefaultMaxDepth = 4
+
+// maxRemoteSources is the number of remote sources of referenced URLs kept for reuse.
+const maxRemoteSources = 256
+
+var _ adapter.RuleSetResolver = (*Manager)(nil)
+
+type Manager struct {
+	ctx          context.Context
+	logger       logger.ContextLogger
+	cache        adapter.Cache
+	sources      map[string]*Source
+	allowedURL   []*url.URL
+	maxDepth     int
+	remoteAccess sync.Mutex
+	remote       *cache.LruCache[string, *source.Remote]
+}
+
+type Source struct {
+	adapter.Source
+	adapter.Convertor
+	option.SourceConvertOptions
+}
+
+func NewManager(ctx context.Context, logger logger.ContextLogger, options option.RuleSetOptions) (*Manager, error) {
+	m := &Manager{
+		ctx:      ctx,
+		logger:   logger,
+		cache:    service.FromContext[adapter.Cache](ctx),
+		sources:  make(map[string]*Source),
+		maxDepth: options.MaxDepth,
+		remote: cache.New[string, *source.Remote](
+			cache.WithSize[string, *source.Remote](maxRemoteSources),
+			cache.WithEvict[string, *source.Remote](func(_ string, remoteSource *source.Remote) {
+				remoteSource.CloseIdleConnections()
+			}),
+		),
+	}
+	if m.maxDepth == 0 {
+		m.maxDepth = DefaultMaxDepth
+	}
+	for i, allowedURL := range options.AllowedURL {
+		parsedURL, err := url.Parse(allowedURL)
+		if err != nil {
+			return nil, E.Cause(err, "parse allowed_url[", i, "]")
+		}
+		if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" || parsedURL.Host == "" {
+			return nil, E.New("parse allowed_url[", i, "]: not a HTTP URL: ", allowedURL)
+		}
+		m.allowedURL = append(m.allowedURL, parsedURL)
+	}
+	if options.Sources != nil {
+		for _, entry := range options.Sources.Entries() {
+			ruleSetSource, err := source.New(ctx, entry.Value.SourceOptions)
+			if err != nil {
+				return nil, E.Cause(err, "create rule-set source: ", entry.Key)
+			}
+			ruleSetConvertor, loaded := convertor.Convertors[entry.Value.SourceType]
+			if !loaded {
+				return nil, E.New("create rule-set source: ", entry.Key, ": unknown source type: ", entry.Value.SourceType)
+			}
+			m.sources[entry.Key] = &Source{
+				Source:               ruleSetSource,
+				Convertor:            ruleSetConvertor,
+				SourceConvertOptions: entry.Value.SourceConvertOptions,
+			}
+		}
+	}
+	return m, nil
+}
+
+type resolveChain struct {
+	parent    *resolveChain
+	reference string
+	depth     int
+}
+
+type resolveChainKey struct{}
+
+func (m *Manager) Resolve(ctx context.Context, reference string, urlOptions option.SourceConvertOptions) ([]adapter.Rule, error) {
+	chain, _ := ctx.Value(resolveChainKey{}).(*resolveChain)
+	for current := chain; current != nil; current = current.parent {
+		if current.reference == reference {
+			return nil, E.New("reference cycle detected")
+		}
+	}
+	var depth int
+	if chain != nil {
+		depth = chain.depth
+	}
+	if depth >= m.maxDepth {
+		return nil, E.New("max depth exceeded: ", m.maxDepth)
+	}
+	var (
+		ruleSetSource  adapter.Source
+		convertOptions option.SourceConvertOptions
+	)
+	if namedSource, loaded := m.sources[reference]; loaded {
+		ruleSetSource = namedSource.Source
+		convertOptions = namedSource.SourceConvertOptions
+	} else if strings.HasPrefix(reference, "http://") || strings.HasPrefix(reference, "https://") {
+		if !m.isAllowedURL(reference) {
+			return nil, E.New("URL not allowed")
+		}
+		remoteSource, err := m.remoteSource(reference)
+		if err != nil {
+			return nil, err
+		}
+		ruleSetSource = remoteSource
+		convertOptions = urlOptions
+	} else {
+		return nil, E.New("unknown rule-set")
+	}
+	ruleSetConvertor, loaded := convertor.Convertors[convertOptions.SourceType]
+	if !loaded {
+		return nil, E.New("unknown source type: ", convertOptions.SourceType)
+	}
+	cachePath, err := ruleSetSource.Path(nil)
+	if err != nil {
+		return nil, E.Cause(err, "evaluate source path")
+	}
+	sourceBinary, err := source.FetchDependency(ctx, m.cache, ruleSetSource, cachePath)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, resolveChainKey{}, &resolveChain{
+		parent:    chain,
+		reference: reference,
+		depth:     depth + 1,
+	})
+	rules, err := ruleSetConvertor.From(ctx, sourceBinary.Content, adapter.ConvertOptions{
+		Options: option.ConvertOptions{
+			SourceConvertOptions: convertOptions,
+		},
+	})
+	if err != nil {
+		return nil, E.Cause(err, "decode source")
+	}
+	return rules, nil
+}
+
+// isAllowedURL checks if the referenced URL has the scheme and host of an allowed URL,
+// and a path under the path of it.
+func (m *Manager) isAllowedURL(reference string) bool {
+	referenceURL, err := url.Parse(reference)
+	if err != nil || referenceURL.User != nil || referenceURL.Opaque != "" {
+		return false
+	}
+	referencePath := referenceURL.Path
+	if referencePath == "" {
+		referencePath = "/"
+	}
+	if path.Clean(referencePath) != strings.TrimSuffix(referencePath, "/") && referencePath != "/" {
+		// dot segments and duplicate slashes.
+		return false
+	}
+	for _, allowedURL := range m.allowedURL {
+		if referenceURL.Scheme != allowedURL.Scheme || !strings.EqualFold(referenceURL.Host, allowedURL.Host) {
+			continue
+		}
+		allowedPath := strings.TrimSuffix(allowedURL.Path, "/")
+		if referencePath == allowedPath || strings.HasPrefix(referencePath, allowedPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) remoteSource(reference string) (adapter.Source, error) {
+	m.remoteAccess.Lock()
+	defer m.remoteAccess.Unlock()
+	if remoteSource, loaded := m.remote.Load(reference); loaded {
+		return remoteSource, nil
+	}
+	remoteSource, err := source.NewRemote(m.ctx, option.SourceOptions{
+		Source: C.EndpointSourceRemote,
+		RemoteOptions: option.RemoteSource{
+			URL: reference,
+		},
+	})
+	if err != nil {
+		return nil, E.Cause(err, "create remote source")
+	}
+	m.remote.Store(reference, remoteSource)
+	return remoteSource, nil
+}
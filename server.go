@@ -1,10 +1,13 @@
 package srsc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sagernet/sing-box/common/listener"
@@ -14,6 +17,7 @@ import (
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/logger"
 	N "github.com/sagernet/sing/common/network"
 	aTLS "github.com/sagernet/sing/common/tls"
@@ -24,20 +28,36 @@ import (
 	"github.com/sagernet/srsc/endpoint"
 	"github.com/sagernet/srsc/option"
 	"github.com/sagernet/srsc/resource"
+	"github.com/sagernet/srsc/ruleset"
+	"github.com/sagernet/srsc/source"
 
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/net/http2"
 )
 
+// ErrRestartRequired is returned by Reload if changed options can only be applied by restarting the server.
+var ErrRestartRequired = E.New("log, listen or TLS options changed, restart required")
+
 type Server struct {
-	createdAt  time.Time
-	ctx        context.Context
-	logger     logger.ContextLogger
-	logFactory log.Factory
-	listener   *listener.Listener
-	tlsConfig  tls.ServerConfig
-	httpServer *http.Server
-	cache      adapter.Cache
+	createdAt    time.Time
+	ctx          context.Context
+	logger       logger.ContextLogger
+	logFactory   log.Factory
+	listener     *listener.Listener
+	tlsConfig    tls.ServerConfig
+	httpServer   *http.Server
+	reloadAccess sync.Mutex
+	current      atomic.Pointer[generation]
+}
+
+// generation holds the routes and services created from options, which are replaced on reload.
+type generation struct {
+	options   option.Options
+	router    *chi.Mux
+	cache     adapter.Cache
+	metrics   *endpoint.MetricsEndpoint
+	resources *resource.Manager
+	health    *endpoint.HealthEndpoint
 }
 
 type Options struct {
@@ -52,7 +72,6 @@ func NewServer(options Options) (*Server, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	ctx = service.ContextWithDefaultRegistry(ctx)
 	if options.Logger == nil {
 		logFactory, err := log.New(log.Options{
 			Context:  ctx,
@@ -65,17 +84,6 @@ func NewServer(options Options) (*Server, error) {
 		options.Logger = logFactory.Logger()
 		// TODO: improve log
 	}
-	serviceCache, err := cache.New(ctx, common.PtrValueOrDefault(options.Cache))
-	if err != nil {
-		return nil, E.Cause(err, "create cache")
-	}
-	service.MustRegister[adapter.Cache](ctx, serviceCache)
-	resourceManage, err := resource.NewManager(ctx, options.Logger, common.PtrValueOrDefault(options.Resources))
-	if err != nil {
-		return nil, E.Cause(err, "create resource manager")
-	}
-	service.MustRegister[adapter.ResourceManager](ctx, resourceManage)
-	chiRouter := chi.NewRouter()
 	s := &Server{
 		createdAt: createdAt,
 		ctx:       ctx,
@@ -89,52 +97,273 @@ func NewServer(options Options) (*Server, error) {
 				ListenPort: options.ListenPort,
 			},
 		}),
-		httpServer: &http.Server{
-			Handler: chiRouter,
-		},
-		cache: serviceCache,
 	}
+	s.httpServer = &http.Server{
+		Handler: http.HandlerFunc(s.serveHTTP),
+	}
+	current, err := s.newGeneration(options.Options, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.current.Store(current)
+	if options.TLS != nil {
+		tlsConfig, err := tls.NewServer(ctx, options.Logger, common.PtrValueOrDefault(options.TLS))
+		if err != nil {
+			common.Close(current.cache)
+			return nil, err
+		}
+		s.tlsConfig = tlsConfig
+	}
+	return s, nil
+}
+
+// newGeneration creates routes and services from options, the cache, metrics and resources of the previous generation are reused if their options are unchanged.
+func (s *Server) newGeneration(options option.Options, previous *generation) (_ *generation, err error) {
+	g := &generation{
+		options: options,
+		router:  chi.NewRouter(),
+	}
+	// services of each generation are registered in a separate registry, so that a failed reload does not affect the running generation.
+	ctx := service.ContextWithRegistry(s.ctx, &generationRegistry{
+		Registry: service.NewRegistry(),
+		parent:   service.RegistryFromContext(s.ctx),
+	})
+	if options.Metrics != nil {
+		if previous != nil && previous.metrics != nil {
+			g.metrics = previous.metrics
+		} else {
+			g.metrics = endpoint.NewMetricsEndpoint()
+		}
+		service.MustRegister[adapter.Metrics](ctx, g.metrics)
+	}
+	if previous != nil && (previous.metrics != nil) == (g.metrics != nil) && equalOptions(previous.options.Cache, options.Cache) {
+		g.cache = previous.cache
+	} else {
+		g.cache, err = cache.New(ctx, common.PtrValueOrDefault(options.Cache))
+		if err != nil {
+			return nil, E.Cause(err, "create cache")
+		}
+		defer func() {
+			if err != nil {
+				common.Close(g.cache)
+			}
+		}()
+	}
+	service.MustRegister[adapter.Cache](ctx, g.cache)
+	if previous != nil && previous.cache == g.cache && equalOptions(previous.options.Resources, options.Resources) {
+		g.resources = previous.resources
+	} else {
+		g.resources, err = resource.NewManager(ctx, s.logger, common.PtrValueOrDefault(options.Resources))
+		if err != nil {
+			return nil, E.Cause(err, "create resource manager")
+		}
+	}
+	service.MustRegister[adapter.ResourceManager](ctx, g.resources)
+	if options.RuleSet != nil {
+		ruleSetManager, err := ruleset.NewManager(ctx, s.logger, *options.RuleSet)
+		if err != nil {
+			return nil, E.Cause(err, "create rule-set manager")
+		}
+		service.MustRegister[adapter.RuleSetResolver](ctx, ruleSetManager)
+	}
+	chiRouter := g.router
 	if options.Endpoints == nil || options.Endpoints.Size() == 0 {
 		return nil, E.New("missing endpoints")
 	}
+	globalAccess := &endpoint.AccessControl{}
+	if options.Access != nil {
+		globalAccess, err = endpoint.NewAccessControl(s.logger, *options.Access)
+		if err != nil {
+			return nil, E.Cause(err, "create access control")
+		}
+	}
+	limiter, err := endpoint.NewLimiter(s.logger, common.PtrValueOrDefault(options.Limit))
+	if err != nil {
+		return nil, E.Cause(err, "create limiter")
+	}
+	var adminEndpoint *endpoint.AdminEndpoint
+	if options.Admin != nil {
+		adminPath := options.Admin.Path
+		if adminPath == "" {
+			adminPath = "/admin"
+		}
+		if !strings.HasPrefix(adminPath, "/") {
+			return nil, E.New("admin path must begin with '/': ", adminPath)
+		}
+		adminAccess := globalAccess
+		if options.Admin.Access != nil {
+			adminAccess, err = endpoint.NewAccessControl(s.logger, *options.Admin.Access)
+			if err != nil {
+				return nil, E.Cause(err, "create admin access control")
+			}
+		}
+		if !adminAccess.Authenticates() {
+			return nil, E.New("admin requires tokens or users in access")
+		}
+		adminEndpoint = endpoint.NewAdminEndpoint(s.logger, g.cache, g.resources, chiRouter)
+		adminPath = strings.TrimSuffix(adminPath, "/")
+		chiRouter.Get(adminPath+"/cache", adminAccess.Handler(adminEndpoint.ListCache))
+		chiRouter.Delete(adminPath+"/cache", adminAccess.Handler(adminEndpoint.PurgeCache))
+		chiRouter.Post(adminPath+"/refresh", adminAccess.Handler(adminEndpoint.Refresh))
+	}
+	// access options of endpoints replace global access options.
+	handle := func(path string, accessOptions *option.AccessOptions, handler http.HandlerFunc, refresher endpoint.SourceRefresher) error {
+		access := globalAccess
+		if accessOptions != nil {
+			var err error
+			access, err = endpoint.NewAccessControl(s.logger, *accessOptions)
+			if err != nil {
+				return E.Cause(err, "create access control: ", path)
+			}
+		}
+		handler = access.Handler(limiter.Handler(handler))
+		if g.metrics != nil {
+			handler = g.metrics.Handler(path, handler)
+		}
+		chiRouter.Get(path, handler)
+		if adminEndpoint != nil {
+			adminEndpoint.AddEndpoint(path, refresher)
+		}
+		return nil
+	}
+	if options.Debug != nil {
+		debugPath := options.Debug.Path
+		if debugPath == "" {
+			debugPath = "/debug"
+		}
+		if !strings.HasPrefix(debugPath, "/") {
+			return nil, E.New("debug path must begin with '/': ", debugPath)
+		}
+		debugEndpoint := endpoint.NewDebugEndpoint()
+		service.MustRegister[adapter.ConvertReportStore](ctx, debugEndpoint)
+		chiRouter.Get(strings.TrimSuffix(debugPath, "/")+"/reports", globalAccess.Handler(debugEndpoint.ServeHTTP))
+	}
+	if g.metrics != nil {
+		metricsPath := options.Metrics.Path
+		if metricsPath == "" {
+			metricsPath = "/metrics"
+		}
+		if !strings.HasPrefix(metricsPath, "/") {
+			return nil, E.New("metrics path must begin with '/': ", metricsPath)
+		}
+		chiRouter.Get(metricsPath, globalAccess.Handler(g.metrics.ServeHTTP))
+	}
+	// the cache is kept across reloads, so converted contents are keyed by options affecting conversions.
+	optionsDigest, err := conversionDigest(options)
+	if err != nil {
+		return nil, E.Cause(err, "digest options")
+	}
+	ctx = endpoint.ContextWithOptionsDigest(ctx, optionsDigest)
+	fileEndpoints := make(map[string]*endpoint.FileEndpoint)
 	for index, entry := range options.Endpoints.Entries() {
 		if !strings.HasPrefix(entry.Key, "/") {
 			return nil, E.New("routing pattern must begin with '/': [", index, "]: ", entry.Key)
 		}
 		switch entry.Value.Type {
 		case C.EndpointTypeFile:
-			handler, err := endpoint.NewFileEndpoint(ctx, options.Logger, index, entry.Value.FileOptions)
+			handler, err := endpoint.NewFileEndpoint(ctx, s.logger, index, entry.Value.FileOptions)
+			if err != nil {
+				return nil, err
+			}
+			err = handle(entry.Key, entry.Value.Access, handler.ServeHTTP, handler)
 			if err != nil {
 				return nil, err
 			}
-			chiRouter.Get(entry.Key, handler.ServeHTTP)
+			fileEndpoints[entry.Key] = handler
+		case C.EndpointTypeBundle, C.EndpointTypeSet, C.EndpointTypeSplit:
 		default:
 			return nil, E.New("unknown endpoint type: " + entry.Value.Type)
 		}
 	}
-	if options.TLS != nil {
-		tlsConfig, err := tls.NewServer(ctx, options.Logger, common.PtrValueOrDefault(options.TLS))
+	// transforms and derived endpoints are created after all file endpoints are registered, since they reference file endpoints by path.
+	for index, entry := range options.Endpoints.Entries() {
+		switch entry.Value.Type {
+		case C.EndpointTypeFile:
+			err := fileEndpoints[entry.Key].InitializeTransforms(chiRouter, fileEndpoints)
+			if err != nil {
+				return nil, E.Cause(err, "create transforms: ", entry.Key)
+			}
+		case C.EndpointTypeBundle:
+			handler, err := endpoint.NewBundleEndpoint(ctx, s.logger, index, entry.Value.BundleOptions, chiRouter, fileEndpoints)
+			if err != nil {
+				return nil, E.Cause(err, "create bundle endpoint: ", entry.Key)
+			}
+			err = handle(entry.Key, entry.Value.Access, handler.ServeHTTP, handler)
+			if err != nil {
+				return nil, err
+			}
+		case C.EndpointTypeSet:
+			handler, err := endpoint.NewSetEndpoint(ctx, s.logger, index, entry.Value.SetOptions, chiRouter, fileEndpoints)
+			if err != nil {
+				return nil, E.Cause(err, "create set endpoint: ", entry.Key)
+			}
+			err = handle(entry.Key, entry.Value.Access, handler.ServeHTTP, handler)
+			if err != nil {
+				return nil, err
+			}
+		case C.EndpointTypeSplit:
+			handler, err := endpoint.NewSplitEndpoint(ctx, s.logger, index, entry.Value.SplitOptions, chiRouter, fileEndpoints)
+			if err != nil {
+				return nil, E.Cause(err, "create split endpoint: ", entry.Key)
+			}
+			err = handle(entry.Key, entry.Value.Access, handler.ServeHTTP, handler)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.Health != nil {
+		g.health, err = endpoint.NewHealthEndpoint(ctx, s.logger, *options.Health, chiRouter, fileEndpoints)
 		if err != nil {
-			return nil, err
+			return nil, E.Cause(err, "create health endpoint")
+		}
+		livenessPath := options.Health.LivenessPath
+		if livenessPath == "" {
+			livenessPath = "/healthz"
+		}
+		readinessPath := options.Health.ReadinessPath
+		if readinessPath == "" {
+			readinessPath = "/readyz"
+		}
+		// probes are registered last, so that endpoints and other routes matching probe paths are kept.
+		for _, probe := range []struct {
+			path    string
+			handler http.HandlerFunc
+		}{
+			{livenessPath, g.health.ServeHealthz},
+			{readinessPath, g.health.ServeReadyz},
+		} {
+			if !strings.HasPrefix(probe.path, "/") {
+				return nil, E.New("health path must begin with '/': ", probe.path)
+			}
+			if chiRouter.Find(chi.NewRouteContext(), http.MethodGet, probe.path) != "" {
+				s.logger.Warn("health path conflicts with other routes, probe is not registered: ", probe.path)
+				continue
+			}
+			chiRouter.Get(probe.path, probe.handler)
 		}
-		s.tlsConfig = tlsConfig
 	}
-	return s, nil
+	return g, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.current.Load().router.ServeHTTP(w, r)
 }
 
 func (s *Server) Start() error {
-	if s.cache != nil {
-		err := s.cache.Start()
-		if err != nil {
-			return E.Cause(err, "start cache")
-		}
+	err := s.current.Load().cache.Start()
+	if err != nil {
+		return E.Cause(err, "start cache")
 	}
 	if s.tlsConfig != nil {
-		err := s.tlsConfig.Start()
+		err = s.tlsConfig.Start()
 		if err != nil {
 			return E.Cause(err, "create TLS config")
 		}
 	}
+	if health := s.current.Load().health; health != nil {
+		health.Start()
+	}
 	tcpListener, err := s.listener.ListenTCP()
 	if err != nil {
 		return err
@@ -146,20 +375,133 @@ func (s *Server) Start() error {
 		tcpListener = aTLS.NewListener(tcpListener, s.tlsConfig)
 	}
 	go func() {
-		err = s.httpServer.Serve(tcpListener)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			s.logger.Error("serve error: ", err)
+		serveErr := s.httpServer.Serve(tcpListener)
+		if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			s.logger.Error("serve error: ", serveErr)
 		}
 	}()
 	s.logger.Info("srsc started (", F.Seconds(time.Since(s.createdAt).Seconds()), "s)")
 	return nil
 }
 
+// Reload replaces routes and endpoints with options in place, while the listener keeps accepting connections.
+// The cache and resources are kept if their options are unchanged, requests in flight are completed by the previous endpoints.
+func (s *Server) Reload(options option.Options) error {
+	s.reloadAccess.Lock()
+	defer s.reloadAccess.Unlock()
+	reloadAt := time.Now()
+	previous := s.current.Load()
+	if !equalOptions(previous.options.Log, options.Log) ||
+		!equalOptions(previous.options.Listen, options.Listen) ||
+		previous.options.ListenPort != options.ListenPort ||
+		!equalOptions(previous.options.TLS, options.TLS) {
+		return ErrRestartRequired
+	}
+	next, err := s.newGeneration(options, previous)
+	if err != nil {
+		return err
+	}
+	if next.cache != previous.cache {
+		err = next.cache.Start()
+		if err != nil {
+			common.Close(next.cache)
+			return E.Cause(err, "start cache")
+		}
+	}
+	s.current.Store(next)
+	if next.health != nil {
+		next.health.Start()
+	}
+	if previous.health != nil {
+		previous.health.Close()
+	}
+	if next.cache != previous.cache {
+		// the previous cache is closed after requests in flight are drained.
+		time.AfterFunc(shutdownTimeout(previous.options), func() {
+			err := previous.cache.Close()
+			if err != nil {
+				s.logger.Error(E.Cause(err, "close previous cache"))
+			}
+		})
+	}
+	s.logger.Info("srsc reloaded (", F.Seconds(time.Since(reloadAt).Seconds()), "s)")
+	return nil
+}
+
+// ShutdownTimeout returns the duration Close waits for requests in flight.
+func (s *Server) ShutdownTimeout() time.Duration {
+	return shutdownTimeout(s.current.Load().options)
+}
+
+// Close stops accepting connections and waits for requests in flight until the shutdown timeout, then closes remaining connections.
 func (s *Server) Close() error {
-	return common.Close(
-		common.PtrOrNil(s.httpServer),
-		common.PtrOrNil(s.listener),
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout())
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.logger.Warn(E.Cause(err, "drain connections"))
+		err = s.httpServer.Close()
+	}
+	// the listener is already closed by the HTTP server if started.
+	listenerErr := s.listener.Close()
+	if E.IsClosed(listenerErr) {
+		listenerErr = nil
+	}
+	current := s.current.Load()
+	if current.health != nil {
+		current.health.Close()
+	}
+	return E.Errors(err, listenerErr, common.Close(
 		s.tlsConfig,
-		s.cache,
-	)
+		current.cache,
+	))
+}
+
+func shutdownTimeout(options option.Options) time.Duration {
+	if options.ShutdownTimeout > 0 {
+		return time.Duration(options.ShutdownTimeout)
+	}
+	return C.DefaultShutdownTimeout
+}
+
+// conversionDigest returns the digest of options of endpoints and of resources and rule-sets referenced by endpoints,
+// access of endpoints is left out since it does not affect converted contents.
+func conversionDigest(options option.Options) (string, error) {
+	var endpoints []any
+	for _, entry := range options.Endpoints.Entries() {
+		endpointOptions := *entry.Value
+		endpointOptions.Access = nil
+		endpoints = append(endpoints, []any{entry.Key, endpointOptions})
+	}
+	content, err := json.Marshal([]any{endpoints, options.Resources, options.RuleSet})
+	if err != nil {
+		return "", err
+	}
+	return source.Digest(content)[:8], nil
+}
+
+func equalOptions(a any, b any) bool {
+	aContent, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bContent, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aContent, bContent)
+}
+
+// generationRegistry looks up services of the parent registry if not registered in the generation.
+type generationRegistry struct {
+	service.Registry
+	parent service.Registry
+}
+
+func (r *generationRegistry) Get(serviceType any) any {
+	registered := r.Registry.Get(serviceType)
+	if registered == nil && r.parent != nil {
+		return r.parent.Get(serviceType)
+	}
+	return registered
 }
@@ -0,0 +1,172 @@
+package srsc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerReload(t *testing.T) {
+	t.Parallel()
+	sourcePath := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(sourcePath, []byte("example.com\n"), 0o644))
+	newOptions := func(listenPort int, endpointPath string) option.Options {
+		content, err := json.Marshal(map[string]any{
+			"listen_port": listenPort,
+			"endpoints": map[string]any{
+				endpointPath: map[string]any{
+					"type":        "file",
+					"source":      "local",
+					"path":        sourcePath,
+					"source_type": "domain-list",
+					"target_type": "domain-list",
+				},
+			},
+		})
+		require.NoError(t, err)
+		options, err := json.UnmarshalExtendedContext[option.Options](context.Background(), content)
+		require.NoError(t, err)
+		return options
+	}
+	server, err := NewServer(Options{Options: newOptions(18080, "/a.txt")})
+	require.NoError(t, err)
+	defer server.Close()
+	request := func(path string) int {
+		recorder := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+	require.Equal(t, http.StatusOK, request("/a.txt"))
+	previous := server.current.Load()
+	require.NoError(t, server.Reload(newOptions(18080, "/b.txt")))
+	require.Equal(t, http.StatusNotFound, request("/a.txt"))
+	require.Equal(t, http.StatusOK, request("/b.txt"))
+	require.Same(t, previous.cache, server.current.Load().cache)
+	require.Same(t, previous.resources, server.current.Load().resources)
+	require.ErrorIs(t, server.Reload(newOptions(18081, "/b.txt")), ErrRestartRequired)
+}
+
+func TestServerReloadTargetOptions(t *testing.T) {
+	t.Parallel()
+	sourcePath := filepath.Join(t.TempDir(), "list.txt")
+	require.NoError(t, os.WriteFile(sourcePath, []byte("example.com\n"), 0o644))
+	newOptions := func(targetType string) option.Options {
+		content, err := json.Marshal(map[string]any{
+			"endpoints": map[string]any{
+				"/list": map[string]any{
+					"type":        "file",
+					"source":      "local",
+					"path":        sourcePath,
+					"source_type": "domain-list",
+					"target_type": targetType,
+				},
+			},
+		})
+		require.NoError(t, err)
+		options, err := json.UnmarshalExtendedContext[option.Options](context.Background(), content)
+		require.NoError(t, err)
+		return options
+	}
+	server, err := NewServer(Options{Options: newOptions("surge")})
+	require.NoError(t, err)
+	defer server.Close()
+	request := func(path string) string {
+		recorder := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Body.String()
+	}
+	require.Equal(t, "DOMAIN,example.com", request("/list"))
+	previous := server.current.Load()
+	require.NoError(t, server.Reload(newOptions("hosts")))
+	require.Same(t, previous.cache, server.current.Load().cache)
+	require.Equal(t, "0.0.0.0 example.com\n", request("/list"))
+}
+
+func TestServerHealthPath(t *testing.T) {
+	t.Parallel()
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "healthz.txt"), []byte("example.com\n"), 0o644))
+	newOptions := func(health map[string]any) option.Options {
+		content, err := json.Marshal(map[string]any{
+			"endpoints": map[string]any{
+				"/{name}": map[string]any{
+					"type":        "file",
+					"source":      "local",
+					"path":        filepath.Join(sourceDir, "{{ .name }}.txt"),
+					"source_type": "domain-list",
+					"target_type": "domain-list",
+				},
+			},
+			"health": health,
+		})
+		require.NoError(t, err)
+		options, err := json.UnmarshalExtendedContext[option.Options](context.Background(), content)
+		require.NoError(t, err)
+		return options
+	}
+	serve := func(server *Server, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+	server, err := NewServer(Options{Options: newOptions(nil)})
+	require.NoError(t, err)
+	require.Nil(t, server.current.Load().health)
+	require.Equal(t, "example.com\n", serve(server, "/healthz").Body.String())
+	require.NoError(t, server.Close())
+	server, err = NewServer(Options{Options: newOptions(map[string]any{})})
+	require.NoError(t, err)
+	require.Equal(t, "example.com\n", serve(server, "/healthz").Body.String())
+	require.NoError(t, server.Close())
+	server, err = NewServer(Options{Options: newOptions(map[string]any{
+		"liveness_path":  "/-/healthz",
+		"readiness_path": "/-/readyz",
+	})})
+	require.NoError(t, err)
+	defer server.Close()
+	require.Equal(t, http.StatusOK, serve(server, "/-/healthz").Code)
+	require.Equal(t, http.StatusOK, serve(server, "/-/readyz").Code)
+}
+
+func TestConversionDigest(t *testing.T) {
+	t.Parallel()
+	newOptions := func(targetType string, token string) option.Options {
+		content, err := json.Marshal(map[string]any{
+			"endpoints": map[string]any{
+				"/list": map[string]any{
+					"type":        "file",
+					"source":      "local",
+					"path":        "list.txt",
+					"source_type": "domain-list",
+					"target_type": targetType,
+					"access": map[string]any{
+						"tokens": []string{token},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		options, err := json.UnmarshalExtendedContext[option.Options](context.Background(), content)
+		require.NoError(t, err)
+		return options
+	}
+	options := newOptions("surge", "a")
+	digest, err := conversionDigest(options)
+	require.NoError(t, err)
+	require.NotNil(t, options.Endpoints.Entries()[0].Value.Access)
+	otherDigest, err := conversionDigest(newOptions("surge", "b"))
+	require.NoError(t, err)
+	require.Equal(t, digest, otherDigest)
+	otherDigest, err = conversionDigest(newOptions("hosts", "a"))
+	require.NoError(t, err)
+	require.NotEqual(t, digest, otherDigest)
+}
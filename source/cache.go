@@ -0,0 +1,118 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+)
+
+// Fetch loads the source content at path from cache, and fetches it from the source if outdated.
+// The cached content is shared by all endpoints and resources with the same source path.
+func Fetch(cache adapter.Cache, source adapter.Source, path string) (*adapter.SavedBinary, error) {
+	cacheKey := "source." + path
+	cachedBinary, err := cache.LoadBinary(cacheKey)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, E.Cause(err, "load cache binary")
+	}
+	if cachedBinary != nil && cachedBinary.SourceDigest == "" {
+		// saved by previous versions.
+		cachedBinary.SourceDigest = Digest(cachedBinary.Content)
+	}
+	lastUpdated := source.LastUpdated(path)
+	if cachedBinary != nil && !lastUpdated.IsZero() && cachedBinary.LastUpdated.Equal(lastUpdated) {
+		return cachedBinary, nil
+	}
+	var fetchBody adapter.FetchRequestBody
+	if cachedBinary != nil {
+		fetchBody.ETag = cachedBinary.LastEtag
+		fetchBody.LastUpdated = cachedBinary.LastUpdated
+	}
+	response, err := source.Fetch(path, fetchBody)
+	if err != nil {
+		return nil, E.Cause(err, "fetch source")
+	}
+	if response.NotModified {
+		if cachedBinary == nil {
+			return nil, E.New("fetch source: unexpected not modified response")
+		}
+		// only the time of the check is updated, so that binaries converted from the source are still derived from it.
+		if response.LastUpdated != cachedBinary.LastUpdated {
+			cachedBinary.LastUpdated = response.LastUpdated
+			err = cache.SaveBinary(cacheKey, cachedBinary)
+			if err != nil {
+				return nil, E.Cause(err, "save cache binary")
+			}
+		}
+		return cachedBinary, nil
+	}
+	return saveFetched(cache, cacheKey, response)
+}
+
+// Refresh fetches the source content at path ignoring the cache, and replaces the cached content if succeeded.
+func Refresh(cache adapter.Cache, source adapter.Source, path string) (*adapter.SavedBinary, error) {
+	response, err := source.Fetch(path, adapter.FetchRequestBody{})
+	if err != nil {
+		return nil, E.Cause(err, "fetch source")
+	}
+	if response.NotModified {
+		return nil, E.New("fetch source: unexpected not modified response")
+	}
+	return saveFetched(cache, "source."+path, response)
+}
+
+func saveFetched(cache adapter.Cache, cacheKey string, response *adapter.FetchResponseBody) (*adapter.SavedBinary, error) {
+	if len(response.Content) == 0 {
+		return nil, E.New("fetch source: empty content")
+	}
+	cachedBinary := &adapter.SavedBinary{
+		Content:      response.Content,
+		LastUpdated:  response.LastUpdated,
+		LastEtag:     response.ETag,
+		SourceDigest: Digest(response.Content),
+	}
+	err := cache.SaveBinary(cacheKey, cachedBinary)
+	if err != nil {
+		return nil, E.Cause(err, "save cache binary")
+	}
+	return cachedBinary, nil
+}
+
+// Digest returns the digest of source content.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:16])
+}
+
+// DerivedDigest returns the source digest of binaries converted from the source binaries.
+func DerivedDigest(sourceBinaries ...*adapter.SavedBinary) string {
+	if len(sourceBinaries) == 1 {
+		return sourceBinaries[0].SourceDigest
+	}
+	hash := sha256.New()
+	for _, sourceBinary := range sourceBinaries {
+		hash.Write([]byte(sourceBinary.SourceDigest + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// IsDerived checks if the derived binary is converted from the current contents of the source binaries,
+// refreshing sources by not modified responses does not outdate derived binaries.
+func IsDerived(derivedBinary *adapter.SavedBinary, sourceBinaries ...*adapter.SavedBinary) bool {
+	return derivedBinary != nil && derivedBinary.SourceDigest != "" && derivedBinary.SourceDigest == DerivedDigest(sourceBinaries...)
+}
+
+// CacheKey appends URL parameters to the cache key, since they may select content from the same source path.
+func CacheKey(prefix string, path string, params map[string]string) string {
+	if len(params) == 0 {
+		return prefix + path
+	}
+	values := make(url.Values)
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	return prefix + path + "?" + values.Encode()
+}
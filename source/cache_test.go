@@ -0,0 +1,52 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/cache"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSource struct {
+	content []byte
+	fetched int
+}
+
+func (s *testSource) Path(urlParams map[string]string) (string, error) {
+	return "test", nil
+}
+
+func (s *testSource) LastUpdated(path string) time.Time {
+	return time.Time{}
+}
+
+func (s *testSource) Fetch(path string, requestBody adapter.FetchRequestBody) (*adapter.FetchResponseBody, error) {
+	s.fetched++
+	if requestBody.ETag == "\"1\"" && string(s.content) == "example.com" {
+		return &adapter.FetchResponseBody{NotModified: true, LastUpdated: time.Now()}, nil
+	}
+	return &adapter.FetchResponseBody{Content: s.content, ETag: "\"1\"", LastUpdated: time.Now()}, nil
+}
+
+func TestFetchNotModified(t *testing.T) {
+	t.Parallel()
+	memoryCache := cache.NewMemory(0)
+	remote := &testSource{content: []byte("example.com")}
+	sourceBinary, err := Fetch(memoryCache, remote, "test")
+	require.NoError(t, err)
+	derivedBinary := &adapter.SavedBinary{SourceDigest: DerivedDigest(sourceBinary)}
+	require.True(t, IsDerived(derivedBinary, sourceBinary))
+	sourceBinary, err = Fetch(memoryCache, remote, "test")
+	require.NoError(t, err)
+	require.Equal(t, 2, remote.fetched)
+	require.True(t, IsDerived(derivedBinary, sourceBinary))
+	remote.content = []byte("example.org")
+	sourceBinary, err = Fetch(memoryCache, remote, "test")
+	require.NoError(t, err)
+	require.Equal(t, "example.org", string(sourceBinary.Content))
+	require.False(t, IsDerived(derivedBinary, sourceBinary))
+	require.False(t, IsDerived(&adapter.SavedBinary{}, sourceBinary))
+}
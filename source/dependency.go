@@ -0,0 +1,76 @@
+package source
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/srsc/adapter"
+)
+
+// Dependency is a source fetched while converting another source, such as a referenced rule-set,
+// binaries converted from the source are outdated by changes of its dependencies as well.
+type Dependency struct {
+	Source adapter.Source
+	Path   string
+}
+
+type dependencyRecorder struct {
+	parent       *dependencyRecorder
+	access       sync.Mutex
+	dependencies []Dependency
+	binaries     []*adapter.SavedBinary
+}
+
+type dependencyRecorderKey struct{}
+
+// RecordDependencies returns a context recording sources fetched by FetchDependency with it,
+// and a function returning the recorded dependencies with their fetched binaries.
+// Dependencies are recorded to recorders of parent contexts as well.
+func RecordDependencies(ctx context.Context) (context.Context, func() ([]Dependency, []*adapter.SavedBinary)) {
+	parent, _ := ctx.Value(dependencyRecorderKey{}).(*dependencyRecorder)
+	recorder := &dependencyRecorder{parent: parent}
+	return context.WithValue(ctx, dependencyRecorderKey{}, recorder), recorder.recorded
+}
+
+func (r *dependencyRecorder) record(dependency Dependency, binary *adapter.SavedBinary) {
+	for current := r; current != nil; current = current.parent {
+		current.access.Lock()
+		if !common.Contains(current.dependencies, dependency) {
+			current.dependencies = append(current.dependencies, dependency)
+			current.binaries = append(current.binaries, binary)
+		}
+		current.access.Unlock()
+	}
+}
+
+func (r *dependencyRecorder) recorded() ([]Dependency, []*adapter.SavedBinary) {
+	r.access.Lock()
+	defer r.access.Unlock()
+	return r.dependencies, r.binaries
+}
+
+// FetchDependency fetches the source like Fetch, and records it as a dependency of the conversion of the context.
+func FetchDependency(ctx context.Context, cache adapter.Cache, source adapter.Source, path string) (*adapter.SavedBinary, error) {
+	binary, err := Fetch(cache, source, path)
+	if err != nil {
+		return nil, err
+	}
+	if recorder, loaded := ctx.Value(dependencyRecorderKey{}).(*dependencyRecorder); loaded {
+		recorder.record(Dependency{Source: source, Path: path}, binary)
+	}
+	return binary, nil
+}
+
+// FetchDependencies fetches current contents of the dependencies, in the order they are recorded.
+func FetchDependencies(cache adapter.Cache, dependencies []Dependency) ([]*adapter.SavedBinary, error) {
+	binaries := make([]*adapter.SavedBinary, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		binary, err := Fetch(cache, dependency.Source, dependency.Path)
+		if err != nil {
+			return nil, err
+		}
+		binaries = append(binaries, binary)
+	}
+	return binaries, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/service"
 	"github.com/sagernet/srsc/adapter"
 	"github.com/sagernet/srsc/option"
 )
@@ -16,6 +17,8 @@ var _ adapter.Source = (*Local)(nil)
 
 type Local struct {
 	pathTemplate *template.Template
+	path         string
+	metrics      adapter.Metrics
 }
 
 func NewLocal(ctx context.Context, options option.SourceOptions) (*Local, error) {
@@ -30,6 +33,8 @@ func NewLocal(ctx context.Context, options option.SourceOptions) (*Local, error)
 	}
 	return &Local{
 		pathTemplate: pathTemplate,
+		path:         options.LocalOptions.Path,
+		metrics:      service.FromContext[adapter.Metrics](ctx),
 	}, nil
 }
 
@@ -53,6 +58,16 @@ func (s *Local) LastUpdated(path string) time.Time {
 }
 
 func (s *Local) Fetch(path string, requestBody adapter.FetchRequestBody) (body *adapter.FetchResponseBody, err error) {
+	if s.metrics == nil {
+		return s.fetch0(path)
+	}
+	startedAt := time.Now()
+	body, err = s.fetch0(path)
+	s.metrics.ObserveFetch(s.path, time.Since(startedAt), false, err)
+	return
+}
+
+func (s *Local) fetch0(path string) (body *adapter.FetchResponseBody, err error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return
@@ -25,6 +25,7 @@ import (
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	aTLS "github.com/sagernet/sing/common/tls"
+	"github.com/sagernet/sing/service"
 	"github.com/sagernet/srsc/adapter"
 	C "github.com/sagernet/srsc/constant"
 	"github.com/sagernet/srsc/option"
@@ -38,6 +39,8 @@ type Remote struct {
 	httpClient   *http.Client
 	userAgent    string
 	ttl          time.Duration
+	url          string
+	metrics      adapter.Metrics
 }
 
 func NewRemote(ctx context.Context, options option.SourceOptions) (*Remote, error) {
@@ -142,6 +145,8 @@ func NewRemote(ctx context.Context, options option.SourceOptions) (*Remote, erro
 		},
 		userAgent: userAgent,
 		ttl:       ttl,
+		url:       options.RemoteOptions.URL,
+		metrics:   service.FromContext[adapter.Metrics](ctx),
 	}, nil
 }
 
@@ -156,6 +161,11 @@ func (s *Remote) Path(urlParams map[string]string) (sourcePath string, err error
 	return
 }
 
+// TTL returns the duration fetched contents are used without checking for updates.
+func (s *Remote) TTL() time.Duration {
+	return s.ttl
+}
+
 func (s *Remote) LastUpdated(_ string) time.Time {
 	return time.Time{}
 }
@@ -167,6 +177,17 @@ func (s *Remote) Fetch(path string, requestBody adapter.FetchRequestBody) (body
 			LastUpdated: requestBody.LastUpdated,
 		}, nil
 	}
+	if s.metrics == nil {
+		return s.fetch0(path, requestBody)
+	}
+	startedAt := time.Now()
+	body, err = s.fetch0(path, requestBody)
+	// sources are identified by URL templates, since evaluated URLs are unbounded.
+	s.metrics.ObserveFetch(s.url, time.Since(startedAt), body != nil && body.NotModified, err)
+	return
+}
+
+func (s *Remote) fetch0(path string, requestBody adapter.FetchRequestBody) (body *adapter.FetchResponseBody, err error) {
 	request, err := http.NewRequestWithContext(s.ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, E.Cause(err, "create HTTP request")
@@ -200,3 +221,8 @@ func (s *Remote) Fetch(path string, requestBody adapter.FetchRequestBody) (body
 		LastUpdated: time.Now(),
 	}, nil
 }
+
+// CloseIdleConnections closes idle connections of the HTTP client.
+func (s *Remote) CloseIdleConnections() {
+	s.httpClient.CloseIdleConnections()
+}
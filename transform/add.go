@@ -0,0 +1,30 @@
+package transform
+
+import (
+	"context"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/option"
+)
+
+var _ adapter.Transform = (*Add)(nil)
+
+// Add appends rules.
+type Add struct {
+	rules []adapter.Rule
+}
+
+func NewAdd(options option.AddRuleTransform) (*Add, error) {
+	if len(options.Rules) == 0 {
+		return nil, E.New("missing rules")
+	}
+	return &Add{
+		rules: common.Map(options.Rules, adapter.RuleFrom),
+	}, nil
+}
+
+func (a *Add) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return append(append([]adapter.Rule(nil), rules...), a.rules...), nil
+}
@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	boxOption "github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/option"
+)
+
+var _ adapter.Transform = (*DropFields)(nil)
+
+// DropFields removes fields from rules.
+type DropFields struct {
+	fieldIndexes []int
+}
+
+func NewDropFields(options option.DropFieldsTransform) (*DropFields, error) {
+	if len(options.Fields) == 0 {
+		return nil, E.New("missing fields")
+	}
+	headlessRuleType := reflect.TypeOf(boxOption.DefaultHeadlessRule{})
+	fieldIndexes := make(map[string]int)
+	for i := 0; i < headlessRuleType.NumField(); i++ {
+		name, _, _ := strings.Cut(headlessRuleType.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || name == "invert" {
+			continue
+		}
+		fieldIndexes[name] = i
+	}
+	dropFields := &DropFields{}
+	for _, field := range options.Fields {
+		fieldIndex, loaded := fieldIndexes[field]
+		if !loaded {
+			return nil, E.New("unknown rule field: ", field)
+		}
+		dropFields.fieldIndexes = append(dropFields.fieldIndexes, fieldIndex)
+	}
+	return dropFields, nil
+}
+
+func (d *DropFields) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return transformRules(rules, func(rule *adapter.DefaultRule) error {
+		ruleValue := reflect.ValueOf(&rule.DefaultHeadlessRule).Elem()
+		for _, fieldIndex := range d.fieldIndexes {
+			field := ruleValue.Field(fieldIndex)
+			field.Set(reflect.Zero(field.Type()))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,204 @@
+package transform
+
+import (
+	"context"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/option"
+
+	"go4.org/netipx"
+)
+
+var _ adapter.Transform = (*Filter)(nil)
+
+// Filter keeps or removes items of rules covered by the patterns.
+type Filter struct {
+	matcher *itemMatcher
+	exclude bool
+}
+
+func NewFilter(options option.FilterRuleTransform, exclude bool) (*Filter, error) {
+	matcher, err := newItemMatcher(options.Domain, options.DomainSuffix, options.DomainKeyword, options.DomainRegex, options.IPCIDR)
+	if err != nil {
+		return nil, err
+	}
+	if !matcher.hasDomain && matcher.ipSet == nil {
+		return nil, E.New("missing patterns")
+	}
+	return &Filter{
+		matcher: matcher,
+		exclude: exclude,
+	}, nil
+}
+
+func (f *Filter) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	return transformRules(rules, func(rule *adapter.DefaultRule) error {
+		return f.matcher.filter(rule, f.exclude)
+	})
+}
+
+// itemMatcher matches items of rules fully covered by the patterns.
+type itemMatcher struct {
+	hasDomain          bool
+	domainSet          map[string]bool
+	suffixSet          map[string]bool
+	subdomainSuffixSet map[string]bool
+	keywords           []string
+	regexSet           map[string]bool
+	regexps            []*regexp.Regexp
+	ipSet              *netipx.IPSet
+}
+
+func newItemMatcher(domains, domainSuffixes, domainKeywords, domainRegexes, ipCIDRs []string) (*itemMatcher, error) {
+	matcher := &itemMatcher{
+		hasDomain:          len(domains) > 0 || len(domainSuffixes) > 0 || len(domainKeywords) > 0 || len(domainRegexes) > 0,
+		domainSet:          make(map[string]bool),
+		suffixSet:          make(map[string]bool),
+		subdomainSuffixSet: make(map[string]bool),
+		regexSet:           make(map[string]bool),
+		keywords:           domainKeywords,
+	}
+	for _, domain := range domains {
+		matcher.domainSet[domain] = true
+	}
+	for _, suffix := range domainSuffixes {
+		if strings.HasPrefix(suffix, ".") {
+			matcher.subdomainSuffixSet[suffix[1:]] = true
+		} else {
+			matcher.suffixSet[suffix] = true
+		}
+	}
+	for _, domainRegex := range domainRegexes {
+		regex, err := regexp.Compile(domainRegex)
+		if err != nil {
+			return nil, E.Cause(err, "parse domain_regex")
+		}
+		matcher.regexSet[domainRegex] = true
+		matcher.regexps = append(matcher.regexps, regex)
+	}
+	if len(ipCIDRs) > 0 {
+		ipSet, err := buildIPSet(ipCIDRs)
+		if err != nil {
+			return nil, E.Cause(err, "parse ip_cidr")
+		}
+		matcher.ipSet = ipSet
+	}
+	return matcher, nil
+}
+
+func (m *itemMatcher) filter(rule *adapter.DefaultRule, exclude bool) error {
+	if m.hasDomain {
+		rule.Domain = filterItems(rule.Domain, m.matchDomain, exclude)
+		rule.DomainSuffix = filterItems(rule.DomainSuffix, m.matchDomainSuffix, exclude)
+		rule.DomainKeyword = filterItems(rule.DomainKeyword, m.matchDomainKeyword, exclude)
+		rule.DomainRegex = filterItems(rule.DomainRegex, m.matchDomainRegex, exclude)
+	}
+	if m.ipSet != nil && len(rule.IPCIDR) > 0 {
+		ipSet, err := buildIPSet(rule.IPCIDR)
+		if err != nil {
+			return E.Cause(err, "parse ip_cidr")
+		}
+		var builder netipx.IPSetBuilder
+		builder.AddSet(ipSet)
+		if exclude {
+			builder.RemoveSet(m.ipSet)
+		} else {
+			builder.Intersect(m.ipSet)
+		}
+		ipSet, err = builder.IPSet()
+		if err != nil {
+			return err
+		}
+		rule.IPCIDR = nil
+		for _, prefix := range ipSet.Prefixes() {
+			rule.IPCIDR = append(rule.IPCIDR, prefix.String())
+		}
+	}
+	return nil
+}
+
+func (m *itemMatcher) matchDomain(domain string) bool {
+	if m.domainSet[domain] || m.suffixSet[domain] || m.matchParent(domain) || m.matchKeyword(domain) {
+		return true
+	}
+	for _, regex := range m.regexps {
+		if regex.MatchString(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomainSuffix matches the suffix if both the domain itself (without leading dot) and all its subdomains are matched.
+func (m *itemMatcher) matchDomainSuffix(suffix string) bool {
+	subdomainOnly := strings.HasPrefix(suffix, ".")
+	domain := strings.TrimPrefix(suffix, ".")
+	if !m.suffixSet[domain] && !m.subdomainSuffixSet[domain] && !m.matchParent(domain) && !m.matchKeyword(domain) {
+		return false
+	}
+	return subdomainOnly || m.matchDomain(domain)
+}
+
+func (m *itemMatcher) matchDomainKeyword(keyword string) bool {
+	return m.matchKeyword(keyword)
+}
+
+func (m *itemMatcher) matchDomainRegex(domainRegex string) bool {
+	return m.regexSet[domainRegex]
+}
+
+func (m *itemMatcher) matchParent(domain string) bool {
+	for {
+		index := strings.IndexByte(domain, '.')
+		if index == -1 {
+			return false
+		}
+		domain = domain[index+1:]
+		if m.suffixSet[domain] || m.subdomainSuffixSet[domain] {
+			return true
+		}
+	}
+}
+
+func (m *itemMatcher) matchKeyword(domain string) bool {
+	for _, keyword := range m.keywords {
+		if strings.Contains(domain, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func filterItems[T ~[]string](items T, match func(item string) bool, exclude bool) T {
+	if len(items) == 0 {
+		return items
+	}
+	var newItems T
+	for _, item := range items {
+		if match(item) != exclude {
+			newItems = append(newItems, item)
+		}
+	}
+	return newItems
+}
+
+func buildIPSet(ipCIDRs []string) (*netipx.IPSet, error) {
+	var builder netipx.IPSetBuilder
+	for _, ipCIDR := range ipCIDRs {
+		prefix, err := netip.ParsePrefix(ipCIDR)
+		if err == nil {
+			builder.AddPrefix(prefix)
+			continue
+		}
+		addr, addrErr := netip.ParseAddr(ipCIDR)
+		if addrErr != nil {
+			return nil, err
+		}
+		builder.Add(addr)
+	}
+	return builder.IPSet()
+}
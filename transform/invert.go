@@ -0,0 +1,27 @@
+package transform
+
+import (
+	"context"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/srsc/adapter"
+)
+
+var _ adapter.Transform = (*Invert)(nil)
+
+// Invert replaces rules with one rule matching anything not matched by them.
+type Invert struct{}
+
+func (i *Invert) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	if len(rules) == 0 {
+		// rules matching nothing are inverted to a rule matching anything.
+		return []adapter.Rule{{Type: boxConstant.RuleTypeDefault}}, nil
+	}
+	rule := wrapRules(rules)
+	if rule.Type == boxConstant.RuleTypeLogical {
+		rule.LogicalOptions.Invert = !rule.LogicalOptions.Invert
+	} else {
+		rule.DefaultOptions.Invert = !rule.DefaultOptions.Invert
+	}
+	return []adapter.Rule{rule}, nil
+}
@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"context"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	"github.com/sagernet/srsc/option"
+)
+
+var _ adapter.Transform = (*Logical)(nil)
+
+// Logical replaces rules with one logical rule of them and the additional rules.
+type Logical struct {
+	mode   string
+	rules  []adapter.Rule
+	invert bool
+}
+
+func NewLogical(options option.LogicalRuleTransform) (*Logical, error) {
+	switch options.Mode {
+	case boxConstant.LogicalTypeAnd, boxConstant.LogicalTypeOr:
+	case "":
+		return nil, E.New("missing logical mode")
+	default:
+		return nil, E.New("unknown logical mode: ", options.Mode)
+	}
+	return &Logical{
+		mode:   options.Mode,
+		rules:  common.Map(options.Rules, adapter.RuleFrom),
+		invert: options.Invert,
+	}, nil
+}
+
+func (l *Logical) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	if len(rules) == 0 && (l.mode == boxConstant.LogicalTypeAnd || len(l.rules) == 0) {
+		// the logical rule matches nothing.
+		if l.invert {
+			return []adapter.Rule{{Type: boxConstant.RuleTypeDefault}}, nil
+		}
+		return nil, nil
+	}
+	var subRules []adapter.Rule
+	if len(rules) > 0 {
+		subRules = append(subRules, wrapRules(rules))
+	}
+	subRules = append(subRules, l.rules...)
+	return []adapter.Rule{{
+		Type: boxConstant.RuleTypeLogical,
+		LogicalOptions: adapter.LogicalRule{
+			Mode:   l.mode,
+			Rules:  subRules,
+			Invert: l.invert,
+		},
+	}}, nil
+}
@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"reflect"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/srsc/adapter"
+)
+
+// transformRules applies the function to non-inverted default rules, inverted rules are kept as is.
+// Rules that match nothing after the transform are removed.
+func transformRules(rules []adapter.Rule, transform func(rule *adapter.DefaultRule) error) ([]adapter.Rule, error) {
+	var newRules []adapter.Rule
+	for _, rule := range rules {
+		newRule, matchAny, err := transformRule(rule, transform)
+		if err != nil {
+			return nil, err
+		}
+		if matchAny {
+			newRules = append(newRules, newRule)
+		}
+	}
+	return newRules, nil
+}
+
+func transformRule(rule adapter.Rule, transform func(rule *adapter.DefaultRule) error) (adapter.Rule, bool, error) {
+	if rule.Type == boxConstant.RuleTypeLogical {
+		if rule.LogicalOptions.Invert {
+			return rule, true, nil
+		}
+		var subRules []adapter.Rule
+		for _, subRule := range rule.LogicalOptions.Rules {
+			newSubRule, matchAny, err := transformRule(subRule, transform)
+			if err != nil {
+				return adapter.Rule{}, false, err
+			}
+			if matchAny {
+				subRules = append(subRules, newSubRule)
+			} else if rule.LogicalOptions.Mode == boxConstant.LogicalTypeAnd {
+				return adapter.Rule{}, false, nil
+			}
+		}
+		if len(subRules) == 0 {
+			return adapter.Rule{}, false, nil
+		}
+		rule.LogicalOptions.Rules = subRules
+		return rule, true, nil
+	}
+	if rule.DefaultOptions.Invert {
+		return rule, true, nil
+	}
+	hadDestinationItems := hasDestinationItems(rule.DefaultOptions)
+	wasEmpty := isEmptyRule(rule.DefaultOptions)
+	err := transform(&rule.DefaultOptions)
+	if err != nil {
+		return adapter.Rule{}, false, err
+	}
+	// an emptied rule or item group would match more instead of less.
+	if hadDestinationItems && !hasDestinationItems(rule.DefaultOptions) || !wasEmpty && isEmptyRule(rule.DefaultOptions) {
+		return adapter.Rule{}, false, nil
+	}
+	return rule, true, nil
+}
+
+func hasDestinationItems(rule adapter.DefaultRule) bool {
+	return len(rule.Domain) > 0 || len(rule.DomainSuffix) > 0 || len(rule.DomainKeyword) > 0 || len(rule.DomainRegex) > 0 ||
+		len(rule.IPCIDR) > 0 || len(rule.GEOIP) > 0 || len(rule.IPASN) > 0
+}
+
+func isEmptyRule(rule adapter.DefaultRule) bool {
+	return reflect.ValueOf(rule).IsZero()
+}
+
+// wrapRules returns the rule matching any of the rules.
+func wrapRules(rules []adapter.Rule) adapter.Rule {
+	if len(rules) == 1 {
+		return rules[0]
+	}
+	return adapter.Rule{
+		Type: boxConstant.RuleTypeLogical,
+		LogicalOptions: adapter.LogicalRule{
+			Mode:  boxConstant.LogicalTypeOr,
+			Rules: rules,
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package transform
+
+import (
+	"context"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+)
+
+var _ adapter.Transform = (*Subtract)(nil)
+
+// Subtract removes items covered by destination address rules of another file endpoint or rule-set source.
+type Subtract struct {
+	loadRules RuleLoader
+}
+
+func NewSubtract(ctx context.Context, options option.SubtractRuleTransform, resolveEndpoint EndpointResolver) (*Subtract, error) {
+	if options.Endpoint != "" && options.RuleSet != "" {
+		return nil, E.New("endpoint and rule_set are mutually exclusive")
+	}
+	if options.Endpoint != "" {
+		loadRules, err := resolveEndpoint(options.Endpoint)
+		if err != nil {
+			return nil, E.Cause(err, "resolve endpoint ", options.Endpoint)
+		}
+		return &Subtract{loadRules}, nil
+	} else if options.RuleSet != "" {
+		resolver := service.FromContext[adapter.RuleSetResolver](ctx)
+		if resolver == nil {
+			return nil, E.New("resolve rule-set ", options.RuleSet, ": missing rule_set configuration")
+		}
+		return &Subtract{func(ctx context.Context, metadata C.Metadata) ([]adapter.Rule, error) {
+			return resolver.Resolve(ctx, options.RuleSet, option.SourceConvertOptions{})
+		}}, nil
+	} else {
+		return nil, E.New("missing endpoint or rule_set")
+	}
+}
+
+func (s *Subtract) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	subtractRules, err := s.loadRules(ctx, options.Metadata)
+	if err != nil {
+		return nil, E.Cause(err, "load subtracted rules")
+	}
+	var (
+		domains        []string
+		domainSuffixes []string
+		domainKeywords []string
+		domainRegexes  []string
+		ipCIDRs        []string
+	)
+	for _, rule := range subtractRules {
+		if rule.Type != boxConstant.RuleTypeDefault || !adapter.IsDestinationAddressRule(rule.DefaultOptions) ||
+			len(rule.DefaultOptions.GEOIP) > 0 || len(rule.DefaultOptions.IPASN) > 0 {
+			options.Report.Drop("subtracted rules with items unsupported by subtract", 1)
+			continue
+		}
+		domains = append(domains, rule.DefaultOptions.Domain...)
+		domainSuffixes = append(domainSuffixes, rule.DefaultOptions.DomainSuffix...)
+		domainKeywords = append(domainKeywords, rule.DefaultOptions.DomainKeyword...)
+		domainRegexes = append(domainRegexes, rule.DefaultOptions.DomainRegex...)
+		ipCIDRs = append(ipCIDRs, rule.DefaultOptions.IPCIDR...)
+	}
+	matcher, err := newItemMatcher(domains, domainSuffixes, domainKeywords, domainRegexes, ipCIDRs)
+	if err != nil {
+		return nil, E.Cause(err, "parse subtracted rules")
+	}
+	return transformRules(rules, func(rule *adapter.DefaultRule) error {
+		return matcher.filter(rule, true)
+	})
+}
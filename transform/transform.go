@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"context"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+)
+
+// RuleLoader loads rules referenced by a transform.
+type RuleLoader func(ctx context.Context, metadata C.Metadata) ([]adapter.Rule, error)
+
+// EndpointResolver returns the loader of source rules of the file endpoint matching the path.
+type EndpointResolver func(path string) (RuleLoader, error)
+
+// New creates the transform, the returned transform is a nil interface if failed.
+func New(ctx context.Context, options option.RuleTransform, resolveEndpoint EndpointResolver) (adapter.Transform, error) {
+	var (
+		transform adapter.Transform
+		err       error
+	)
+	switch options.Type {
+	case C.TransformTypeInclude:
+		transform, err = NewFilter(options.FilterOptions, false)
+	case C.TransformTypeExclude:
+		transform, err = NewFilter(options.FilterOptions, true)
+	case C.TransformTypeAdd:
+		transform, err = NewAdd(options.AddOptions)
+	case C.TransformTypeDropFields:
+		transform, err = NewDropFields(options.DropFieldsOptions)
+	case C.TransformTypeInvert:
+		transform = &Invert{}
+	case C.TransformTypeLogical:
+		transform, err = NewLogical(options.LogicalOptions)
+	case C.TransformTypeSubtract:
+		transform, err = NewSubtract(ctx, options.SubtractOptions, resolveEndpoint)
+	default:
+		err = E.New("unknown transform type: " + options.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transform, nil
+}
+
+var _ adapter.Transform = (Pipeline)(nil)
+
+// Pipeline applies transforms in order.
+type Pipeline []adapter.Transform
+
+func NewPipeline(ctx context.Context, options []option.RuleTransform, resolveEndpoint EndpointResolver) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(options))
+	for index, transformOptions := range options {
+		transform, err := New(ctx, transformOptions, resolveEndpoint)
+		if err != nil {
+			return nil, E.Cause(err, "transforms[", index, "]")
+		}
+		pipeline = append(pipeline, transform)
+	}
+	return pipeline, nil
+}
+
+func (p Pipeline) Apply(ctx context.Context, rules []adapter.Rule, options adapter.ConvertOptions) ([]adapter.Rule, error) {
+	for index, transform := range p {
+		var err error
+		rules, err = transform.Apply(ctx, rules, options)
+		if err != nil {
+			return nil, E.Cause(err, "transforms[", index, "]")
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,138 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	boxConstant "github.com/sagernet/sing-box/constant"
+	boxOption "github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/srsc/adapter"
+	C "github.com/sagernet/srsc/constant"
+	"github.com/sagernet/srsc/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRules() []adapter.Rule {
+	return []adapter.Rule{
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+				Domain:        []string{"example.com", "a.example.org"},
+				DomainSuffix:  []string{"example.net", ".example.org"},
+				DomainKeyword: []string{"tracker"},
+				IPCIDR:        []string{"10.0.0.0/8", "2001:db8::/32"},
+			}),
+		},
+		{
+			Type: boxConstant.RuleTypeDefault,
+			DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+				Domain: []string{"example.com"},
+				Port:   []uint16{443},
+			}),
+		},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	pipeline, err := NewPipeline(context.Background(), []option.RuleTransform{
+		{
+			Type: C.TransformTypeExclude,
+			FilterOptions: option.FilterRuleTransform{
+				DomainSuffix: []string{"example.org", "example.com"},
+				IPCIDR:       []string{"10.1.0.0/16"},
+			},
+		},
+		{
+			Type: C.TransformTypeInclude,
+			FilterOptions: option.FilterRuleTransform{
+				IPCIDR: []string{"0.0.0.0/0"},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	rules, err := pipeline.Apply(context.Background(), testRules(), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Empty(t, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.net"}, rules[0].DefaultOptions.DomainSuffix)
+	require.Equal(t, badoption.Listable[string]{"tracker"}, rules[0].DefaultOptions.DomainKeyword)
+	require.Equal(t, badoption.Listable[string]{"10.0.0.0/16", "10.2.0.0/15", "10.4.0.0/14", "10.8.0.0/13", "10.16.0.0/12", "10.32.0.0/11", "10.64.0.0/10", "10.128.0.0/9"}, rules[0].DefaultOptions.IPCIDR)
+}
+
+func TestDropFields(t *testing.T) {
+	t.Parallel()
+	dropFields, err := NewDropFields(option.DropFieldsTransform{Fields: []string{"domain_keyword", "port"}})
+	require.NoError(t, err)
+	rules, err := dropFields.Apply(context.Background(), testRules(), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Empty(t, rules[0].DefaultOptions.DomainKeyword)
+	require.Empty(t, rules[1].DefaultOptions.Port)
+	_, err = NewDropFields(option.DropFieldsTransform{Fields: []string{"invert"}})
+	require.Error(t, err)
+}
+
+func TestLogical(t *testing.T) {
+	t.Parallel()
+	pipeline, err := NewPipeline(context.Background(), []option.RuleTransform{
+		{
+			Type: C.TransformTypeLogical,
+			LogicalOptions: option.LogicalRuleTransform{
+				Mode: boxConstant.LogicalTypeAnd,
+				Rules: []boxOption.HeadlessRule{{
+					Type: boxConstant.RuleTypeDefault,
+					DefaultOptions: boxOption.DefaultHeadlessRule{
+						Network: []string{"tcp"},
+					},
+				}},
+			},
+		},
+		{Type: C.TransformTypeInvert},
+	}, nil)
+	require.NoError(t, err)
+	rules, err := pipeline.Apply(context.Background(), testRules(), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, boxConstant.RuleTypeLogical, rules[0].Type)
+	require.True(t, rules[0].LogicalOptions.Invert)
+	require.Equal(t, boxConstant.LogicalTypeAnd, rules[0].LogicalOptions.Mode)
+	require.Len(t, rules[0].LogicalOptions.Rules, 2)
+	require.Equal(t, boxConstant.LogicalTypeOr, rules[0].LogicalOptions.Rules[0].LogicalOptions.Mode)
+}
+
+func TestSubtract(t *testing.T) {
+	t.Parallel()
+	subtract, err := NewSubtract(context.Background(), option.SubtractRuleTransform{Endpoint: "/other.srs"}, func(path string) (RuleLoader, error) {
+		require.Equal(t, "/other.srs", path)
+		return func(ctx context.Context, metadata C.Metadata) ([]adapter.Rule, error) {
+			return []adapter.Rule{{
+				Type: boxConstant.RuleTypeDefault,
+				DefaultOptions: adapter.DefaultRuleFrom(boxOption.DefaultHeadlessRule{
+					Domain:       []string{"example.com"},
+					DomainSuffix: []string{"b.example.net"},
+				}),
+			}}, nil
+		}, nil
+	})
+	require.NoError(t, err)
+	rules, err := subtract.Apply(context.Background(), testRules(), adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, badoption.Listable[string]{"a.example.org"}, rules[0].DefaultOptions.Domain)
+	require.Equal(t, badoption.Listable[string]{"example.net", ".example.org"}, rules[0].DefaultOptions.DomainSuffix)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	transform, err := New(context.Background(), option.RuleTransform{Type: C.TransformTypeInclude}, nil)
+	require.ErrorContains(t, err, "missing patterns")
+	require.True(t, transform == nil)
+	transform, err = New(context.Background(), option.RuleTransform{Type: C.TransformTypeInvert}, nil)
+	require.NoError(t, err)
+	rules, err := transform.Apply(context.Background(), nil, adapter.ConvertOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+}